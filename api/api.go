@@ -0,0 +1,99 @@
+//Package api defines the responses of the endpoints in JSON format,
+//which the handlers send and the client package decodes.
+package api
+
+import (
+	"encoding/json"
+)
+
+//Values is a multi-valued map like url.Values and http.Header in the format of httpbin:
+//a key with a single value is a string and a repeated key is an array of its values in order.
+type Values map[string][]string
+
+//Get returns the first value of the key, "" if there is none.
+func (v Values) Get(key string) string{
+	if len(v[key]) == 0 {
+		return ""
+	}
+	return v[key][0]
+}
+
+//MarshalJSON encodes a single value as a string and several values as an array.
+func (v Values) MarshalJSON() ([]byte, error){
+	flat := make(map[string]interface{}, len(v))
+	for k, vals := range v {
+		if len(vals) == 1 {
+			flat[k] = vals[0]
+		} else {
+			flat[k] = vals
+		}
+	}
+	return json.Marshal(flat)
+}
+
+//UnmarshalJSON decodes the values of every key from either a string or an array.
+func (v *Values) UnmarshalJSON(data []byte) error{
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return err
+	}
+	if flat == nil {
+		*v = nil
+		return nil
+	}
+	values := make(Values, len(flat))
+	for k, raw := range flat {
+		var one string
+		if err := json.Unmarshal(raw, &one); err == nil {
+			values[k] = []string{one}
+			continue
+		}
+		var many []string
+		if err := json.Unmarshal(raw, &many); err != nil {
+			return err
+		}
+		values[k] = many
+	}
+	*v = values
+	return nil
+}
+
+//Upload is the metadata of a file of a multipart/form-data request.
+type Upload struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+//EchoResponse is what the echo endpoints like /get, /post and /anything send about a request.
+//Every endpoint sends only some of the fields, the others are left empty when it is decoded.
+type EchoResponse struct {
+	Args    Values   `json:"args"`
+	Data    string   `json:"data"`
+	Files   Values   `json:"files"`
+	Uploads []Upload `json:"uploads"`
+	Form    Values   `json:"form"`
+	Headers Values   `json:"headers"`
+	//JSON is the decoded body if its Content-Type is JSON, null otherwise.
+	JSON json.RawMessage `json:"json"`
+	//Error is why the body could not be decoded as JSON.
+	Error         string `json:"error,omitempty"`
+	Origin        string `json:"origin"`
+	URL           string `json:"url"`
+	Method        string `json:"method"`
+	Protocol      string `json:"protocol"`
+	UserAgent     string `json:"user-agent"`
+	UUID          string `json:"uuid"`
+	Gzipped       bool   `json:"gzipped"`
+	Deflated      bool   `json:"deflated"`
+	Brotli        bool   `json:"brotli"`
+	Authenticated bool   `json:"authenticated"`
+	User          string `json:"user"`
+}
+
+//DecodeJSON decodes the JSON field into v.
+func (e *EchoResponse) DecodeJSON(v interface{}) error{
+	return json.Unmarshal(e.JSON, v)
+}
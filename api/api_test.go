@@ -0,0 +1,28 @@
+package api
+
+import(
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValuesJSON(t *testing.T){
+	values := Values{"one":{"a"},"many":{"b","c"}}
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"many":["b","c"],"one":"a"}`; string(data) != expected{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",expected,string(data))
+	}
+	var decoded Values
+	if err := json.Unmarshal(data,&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded,values){
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",values,decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"bad":1}`),&decoded); err == nil{
+		t.Error("Expected an error for a value which is not a string or an array of strings")
+	}
+}
@@ -0,0 +1,174 @@
+//Package client calls the endpoints of a responsiveweb server and decodes their responses into the types of the api package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tahasevim/responsiveweb/api"
+)
+
+//Client calls the endpoints of the server at BaseURL.
+type Client struct {
+	//BaseURL is the URL which the paths of the endpoints are appended to, like http://localhost:8080.
+	BaseURL string
+	//HTTPClient makes the requests, http.DefaultClient is used if it is nil.
+	HTTPClient *http.Client
+}
+
+//New returns a client of the server at baseURL.
+func New(baseURL string) *Client{
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+//StatusError is returned when an endpoint answers with a status code which is not 2xx.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string{
+	return fmt.Sprintf("unexpected status code %d: %s", e.Code, strings.TrimSpace(e.Body))
+}
+
+//Get calls /get with the query args.
+func (c *Client) Get(ctx context.Context, args url.Values) (*api.EchoResponse, error){
+	path := "/get"
+	if len(args) > 0 {
+		path += "?" + args.Encode()
+	}
+	return c.echo(ctx, http.MethodGet, path, nil)
+}
+
+//Post calls /post with body, see Anything for how it is encoded.
+func (c *Client) Post(ctx context.Context, body interface{}) (*api.EchoResponse, error){
+	return c.echo(ctx, http.MethodPost, "/post", body)
+}
+
+//Put calls /put with body, see Anything for how it is encoded.
+func (c *Client) Put(ctx context.Context, body interface{}) (*api.EchoResponse, error){
+	return c.echo(ctx, http.MethodPut, "/put", body)
+}
+
+//Patch calls /patch with body, see Anything for how it is encoded.
+func (c *Client) Patch(ctx context.Context, body interface{}) (*api.EchoResponse, error){
+	return c.echo(ctx, http.MethodPatch, "/patch", body)
+}
+
+//Delete calls /delete with body, see Anything for how it is encoded.
+func (c *Client) Delete(ctx context.Context, body interface{}) (*api.EchoResponse, error){
+	return c.echo(ctx, http.MethodDelete, "/delete", body)
+}
+
+//Anything calls /anything with the method and body.
+//A url.Values body is sent as a form, a string, []byte or io.Reader as it is, nil as no body and anything else in JSON format.
+func (c *Client) Anything(ctx context.Context, method string, body interface{}) (*api.EchoResponse, error){
+	return c.echo(ctx, method, "/anything", body)
+}
+
+//Headers calls /headers and returns the headers which the server received.
+func (c *Client) Headers(ctx context.Context) (api.Values, error){
+	resp, err := c.echo(ctx, http.MethodGet, "/headers", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Headers, nil
+}
+
+//IP calls /ip and returns the address of the client which the server saw.
+func (c *Client) IP(ctx context.Context) (string, error){
+	resp, err := c.echo(ctx, http.MethodGet, "/ip", nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Origin, nil
+}
+
+//Status calls /status/code and returns the status code of the response, which is not followed if it is a redirect.
+//Unlike the other calls, it does not return a StatusError for a code which is not 2xx.
+func (c *Client) Status(ctx context.Context, code int) (int, error){
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/status/"+strconv.Itoa(code), nil)
+	if err != nil {
+		return 0, err
+	}
+	hc := *c.httpClient()
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error{
+		return http.ErrUseLastResponse
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+//httpClient returns the client which makes the requests.
+func (c *Client) httpClient() *http.Client{
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+//echo makes a request to an echo endpoint and decodes its response.
+func (c *Client) echo(ctx context.Context, method, path string, body interface{}) (*api.EchoResponse, error){
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(data)}
+	}
+	var echo api.EchoResponse
+	if err := json.Unmarshal(data, &echo); err != nil {
+		return nil, fmt.Errorf("decoding the response of %s %s: %v", method, path, err)
+	}
+	return &echo, nil
+}
+
+//encodeBody returns the body of a request and its Content-Type, see Anything.
+func encodeBody(body interface{}) (io.Reader, string, error){
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case url.Values:
+		return strings.NewReader(b.Encode()), "application/x-www-form-urlencoded", nil
+	case string:
+		return strings.NewReader(b), "text/plain; charset=utf-8", nil
+	case []byte:
+		return bytes.NewReader(b), "application/octet-stream", nil
+	case io.Reader:
+		return b, "application/octet-stream", nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(data), "application/json", nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import(
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"github.com/tahasevim/responsiveweb/handlers"
+	"github.com/tahasevim/responsiveweb/handlers/handlerstest"
+)
+
+func TestClientEcho(t *testing.T){
+	ts := handlerstest.NewServer(t)
+	c := New(ts.URL+"/")
+	ctx := context.Background()
+
+	get, err := c.Get(ctx,url.Values{"k":{"v1","v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get.URL != ts.URL+"/get?k=v1&k=v2" || len(get.Args["k"]) != 2 || get.Headers.Get("Host") == ""{
+		t.Errorf("Unexpected GET response:%+v",get)
+	}
+
+	post, err := c.Post(ctx,map[string]interface{}{"name":"pig","legs":4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{
+		Name string `json:"name"`
+		Legs int `json:"legs"`
+	}
+	if err := post.DecodeJSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Name != "pig" || body.Legs != 4 || post.Headers.Get("Content-Type") != "application/json"{
+		t.Errorf("Unexpected POST response:%+v",post)
+	}
+
+	put, err := c.Put(ctx,url.Values{"a":{"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put.Form.Get("a") != "1" || string(put.JSON) != "null"{
+		t.Errorf("Unexpected PUT response:%+v",put)
+	}
+
+	anything, err := c.Anything(ctx,"PATCH","raw body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anything.Method != "PATCH" || anything.Data != "raw body"{
+		t.Errorf("Unexpected /anything response:%+v",anything)
+	}
+
+	ip, err := c.IP(ctx)
+	if err != nil || ip != "127.0.0.1"{
+		t.Errorf("Unexpected IP:%v %v",ip,err)
+	}
+}
+
+func TestClientStatus(t *testing.T){
+	ts := handlerstest.NewServer(t)
+	c := New(ts.URL)
+	for _,code := range []int{200,302,418,503}{
+		got, err := c.Status(context.Background(),code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",code,got)
+		}
+	}
+}
+
+func TestClientStatusError(t *testing.T){
+	ts := handlerstest.NewServer(t,handlers.WithGroups("status"))
+	_, err := New(ts.URL).Post(context.Background(),nil)
+	var statusErr *StatusError
+	if !errors.As(err,&statusErr) || statusErr.Code != http.StatusNotFound{
+		t.Errorf("Unexpected error:%v",err)
+	}
+}
@@ -0,0 +1,419 @@
+//Package config loads the configuration of the server from a YAML or JSON file, environment variables and command line flags.
+//Flags override environment variables, which override the file, which overrides the defaults.
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tahasevim/responsiveweb/handlers"
+	"github.com/tahasevim/responsiveweb/server"
+	"gopkg.in/yaml.v2"
+)
+
+//EnvPrefix is the prefix of the environment variables, like RESPONSIVEWEB_ADDRESS for the address setting.
+const EnvPrefix = "RESPONSIVEWEB_"
+
+//Duration is a time.Duration which is written like "10s" or "1m30s" in configuration files.
+type Duration time.Duration
+
+//UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error{
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %v", err)
+	}
+	return d.set(s)
+}
+
+//UnmarshalYAML parses a duration string.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error{
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.set(s)
+}
+
+//set parses a duration string like time.ParseDuration.
+func (d *Duration) set(s string) error{
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+//String returns the duration like time.Duration.String.
+func (d Duration) String() string{
+	return time.Duration(d).String()
+}
+
+//Limits are the upper bounds of the parameters of the endpoints, see handlers.Limits.
+type Limits struct {
+	StreamLines int      `yaml:"stream_lines" json:"stream_lines"`
+	Delay       Duration `yaml:"delay" json:"delay"`
+	Bytes       int      `yaml:"bytes" json:"bytes"`
+	StreamBytes int      `yaml:"stream_bytes" json:"stream_bytes"`
+}
+
+//TLS is the configuration of the HTTPS listener.
+type TLS struct {
+	//Address is the TCP address to listen on for HTTPS, like ":8443". HTTPS is disabled if it is empty.
+	Address string `yaml:"address" json:"address"`
+	//CertFile and KeyFile are the PEM files of the certificate chain and its key.
+	//If they are empty, a self-signed CA and a certificate signed by it are created in CertDir.
+	CertFile string `yaml:"cert_file" json:"cert_file"`
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+	CertDir  string `yaml:"cert_dir" json:"cert_dir"`
+	//Hosts are the DNS names and IP addresses of the created certificate.
+	Hosts []string `yaml:"hosts" json:"hosts"`
+	//ClientAuth is "none", "optional" or "require", whether clients are asked for certificates or must present one.
+	//The certificates are verified by /client-cert, so that a failed verification is answered with 403 instead of a failed handshake.
+	ClientAuth string `yaml:"client_auth" json:"client_auth"`
+	//ClientCAFile is the PEM file of the CAs which client certificates are verified against.
+	//If it is empty, the created CA in CertDir is used, or the system roots if the certificate files are given.
+	ClientCAFile string `yaml:"client_ca_file" json:"client_ca_file"`
+}
+
+//clientAuthTypes are the TLS client authentication types of the ClientAuth values.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.RequestClientCert,
+	"require":  tls.RequireAnyClientCert,
+}
+
+//Config is the configuration of the server.
+type Config struct {
+	//Address is the TCP address to listen on, like ":8080".
+	Address           string   `yaml:"address" json:"address"`
+	ReadTimeout       Duration `yaml:"read_timeout" json:"read_timeout"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" json:"read_header_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" json:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	MaxHeaderBytes    int      `yaml:"max_header_bytes" json:"max_header_bytes"`
+	//ShutdownDelay is how long requests are still served after /ready reports that the server is shutting down.
+	ShutdownDelay Duration `yaml:"shutdown_delay" json:"shutdown_delay"`
+	//ShutdownTimeout is how long in-flight requests are waited for on shutdown.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+	//MaxBodySize is the largest request body in bytes accepted by the endpoints.
+	MaxBodySize int64 `yaml:"max_body_size" json:"max_body_size"`
+	//TrustedProxies are the IP addresses and CIDR networks of the proxies whose forwarding headers are trusted.
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+	Limits         Limits   `yaml:"limits" json:"limits"`
+	//Endpoints are the enabled endpoint groups, all groups are enabled if it is empty. /ready is always enabled.
+	Endpoints []string `yaml:"endpoints" json:"endpoints"`
+	TLS       TLS      `yaml:"tls" json:"tls"`
+}
+
+//Default returns the default configuration.
+//The write timeout leaves room for the longest /delay/:n and /drip responses.
+func Default() *Config{
+	return &Config{
+		Address:           ":8080",
+		ReadTimeout:       Duration(30 * time.Second),
+		ReadHeaderTimeout: Duration(10 * time.Second),
+		WriteTimeout:      Duration(2 * time.Minute),
+		IdleTimeout:       Duration(2 * time.Minute),
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		ShutdownTimeout:   Duration(30 * time.Second),
+		MaxBodySize:       handlers.MaxUploadSize,
+		TrustedProxies:    []string{"127.0.0.0/8", "::1/128"},
+		Limits: Limits{
+			StreamLines: handlers.EndpointLimits.StreamLines,
+			Delay:       Duration(handlers.EndpointLimits.Delay),
+			Bytes:       handlers.EndpointLimits.Bytes,
+			StreamBytes: handlers.EndpointLimits.StreamBytes,
+		},
+		TLS: TLS{
+			CertDir:    "certs",
+			Hosts:      []string{"localhost", "127.0.0.1", "::1"},
+			ClientAuth: "none",
+		},
+	}
+}
+
+//setting is a configuration value which can be set by an environment variable and a flag.
+//The environment variable is EnvPrefix followed by the upper case name with '-' replaced by '_'.
+type setting struct {
+	name  string
+	usage string
+	get   func(c *Config) string
+	set   func(c *Config, s string) error
+}
+
+//durationSetting returns a setting of a Duration field.
+func durationSetting(name, usage string, field func(c *Config) *Duration) setting{
+	return setting{name, usage,
+		func(c *Config) string{ return field(c).String() },
+		func(c *Config, s string) error{ return field(c).set(s) },
+	}
+}
+
+//intSetting returns a setting of an int field.
+func intSetting(name, usage string, field func(c *Config) *int) setting{
+	return setting{name, usage,
+		func(c *Config) string{ return strconv.Itoa(*field(c)) },
+		func(c *Config, s string) (err error){
+			*field(c), err = strconv.Atoi(s)
+			return
+		},
+	}
+}
+
+//listSetting returns a setting of a list field which is written as comma separated values.
+func listSetting(name, usage string, field func(c *Config) *[]string) setting{
+	return setting{name, usage,
+		func(c *Config) string{ return strings.Join(*field(c), ",") },
+		func(c *Config, s string) error{
+			*field(c) = nil
+			for _, v := range strings.Split(s, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					*field(c) = append(*field(c), v)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+//settings are all settings which can be set by environment variables and flags, the file can set every field of Config.
+var settings = []setting{
+	{"address", "TCP address to listen on",
+		func(c *Config) string{ return c.Address },
+		func(c *Config, s string) error{ c.Address = s; return nil }},
+	durationSetting("read-timeout", "maximum duration for reading a whole request", func(c *Config) *Duration{ return &c.ReadTimeout }),
+	durationSetting("read-header-timeout", "maximum duration for reading request headers", func(c *Config) *Duration{ return &c.ReadHeaderTimeout }),
+	durationSetting("write-timeout", "maximum duration for writing a response", func(c *Config) *Duration{ return &c.WriteTimeout }),
+	durationSetting("idle-timeout", "maximum duration to wait for the next request on a keep-alive connection", func(c *Config) *Duration{ return &c.IdleTimeout }),
+	durationSetting("shutdown-delay", "how long requests are still served after /ready starts answering 503 on shutdown", func(c *Config) *Duration{ return &c.ShutdownDelay }),
+	durationSetting("shutdown-timeout", "how long in-flight requests are waited for on shutdown", func(c *Config) *Duration{ return &c.ShutdownTimeout }),
+	intSetting("max-header-bytes", "largest size of request headers in bytes", func(c *Config) *int{ return &c.MaxHeaderBytes }),
+	{"max-body-size", "largest request body in bytes accepted by the endpoints",
+		func(c *Config) string{ return strconv.FormatInt(c.MaxBodySize, 10) },
+		func(c *Config, s string) (err error){
+			c.MaxBodySize, err = strconv.ParseInt(s, 10, 64)
+			return
+		}},
+	listSetting("trusted-proxies", "comma separated IP addresses and CIDR networks of the proxies whose forwarding headers are trusted", func(c *Config) *[]string{ return &c.TrustedProxies }),
+	intSetting("limit-stream-lines", "largest n of /stream/:n", func(c *Config) *int{ return &c.Limits.StreamLines }),
+	durationSetting("limit-delay", "longest delay of /delay/:n, delay and duration of /drip and duration of /range/:n", func(c *Config) *Duration{ return &c.Limits.Delay }),
+	intSetting("limit-bytes", "largest n of /bytes/:n and /range/:n", func(c *Config) *int{ return &c.Limits.Bytes }),
+	intSetting("limit-stream-bytes", "largest n of /stream-bytes/:n and numbytes of /drip", func(c *Config) *int{ return &c.Limits.StreamBytes }),
+	{"tls-address", "TCP address to listen on for HTTPS, disabled if empty",
+		func(c *Config) string{ return c.TLS.Address },
+		func(c *Config, s string) error{ c.TLS.Address = s; return nil }},
+	{"tls-cert-file", "PEM certificate chain file, a self-signed one is created if empty",
+		func(c *Config) string{ return c.TLS.CertFile },
+		func(c *Config, s string) error{ c.TLS.CertFile = s; return nil }},
+	{"tls-key-file", "PEM private key file of the certificate",
+		func(c *Config) string{ return c.TLS.KeyFile },
+		func(c *Config, s string) error{ c.TLS.KeyFile = s; return nil }},
+	{"tls-cert-dir", "directory of the created CA and certificate",
+		func(c *Config) string{ return c.TLS.CertDir },
+		func(c *Config, s string) error{ c.TLS.CertDir = s; return nil }},
+	listSetting("tls-hosts", "comma separated DNS names and IP addresses of the created certificate", func(c *Config) *[]string{ return &c.TLS.Hosts }),
+	{"tls-client-auth", "whether client certificates are requested: none, optional or require",
+		func(c *Config) string{ return c.TLS.ClientAuth },
+		func(c *Config, s string) error{ c.TLS.ClientAuth = s; return nil }},
+	{"tls-client-ca-file", "PEM file of the CAs which client certificates are verified against",
+		func(c *Config) string{ return c.TLS.ClientCAFile },
+		func(c *Config, s string) error{ c.TLS.ClientCAFile = s; return nil }},
+	listSetting("endpoints", "comma separated endpoint groups to enable, all if empty, /ready is always enabled ("+strings.Join(handlers.Groups(), ", ")+")", func(c *Config) *[]string{ return &c.Endpoints }),
+}
+
+//envName returns the environment variable of the setting.
+func envName(name string) string{
+	return EnvPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+//Load returns the configuration from the defaults, the configuration file, the environment and the command line arguments, in increasing precedence.
+//The file is given by the -config flag or the RESPONSIVEWEB_CONFIG environment variable, its format is chosen by its extension.
+//The legacy -port flag sets the address to ":port".
+func Load(name string, args []string) (*Config, error){
+	c := Default()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(EnvPrefix+"CONFIG"), "YAML or JSON configuration file")
+	port := fs.String("port", "", "port to listen on, it is a shorthand for -address=:port")
+	values := make([]*string, len(settings))
+	for i, s := range settings {
+		values[i] = fs.String(s.name, s.get(c), s.usage+" (env "+envName(s.name)+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := c.readFile(*file); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(envName(s.name)); ok {
+			if err := s.set(c, v); err != nil {
+				return nil, fmt.Errorf("%s: %v", envName(s.name), err)
+			}
+		}
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag){
+		for i, s := range settings {
+			if s.name == f.Name && err == nil {
+				if e := s.set(c, *values[i]); e != nil {
+					err = fmt.Errorf("-%s: %v", s.name, e)
+				}
+			}
+		}
+		if f.Name == "port" {
+			c.Address = ":" + *port
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, c.Validate()
+}
+
+//readFile reads the configuration file over c, JSON if its extension is .json and YAML otherwise.
+//Unknown keys are errors so that misspelled settings are not ignored.
+func (c *Config) readFile(file string) error{
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	} else {
+		err = yaml.UnmarshalStrict(data, c)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	return nil
+}
+
+//Validate checks the values which cannot be checked while they are parsed.
+func (c *Config) Validate() error{
+	if _, err := handlers.ParseTrustedProxies(strings.Join(c.TrustedProxies, ",")); err != nil {
+		return err
+	}
+	groups := handlers.Groups()
+	for _, endpoint := range c.Endpoints {
+		known := false
+		for _, group := range groups {
+			known = known || endpoint == group
+		}
+		if !known {
+			return fmt.Errorf("unknown endpoint group %q, the groups are %s", endpoint, strings.Join(groups, ", "))
+		}
+	}
+	if c.MaxBodySize <= 0 || c.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("max body size and max header bytes must be positive")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("TLS cert file and key file must be given together")
+	}
+	if _, ok := clientAuthTypes[c.TLS.ClientAuth]; !ok {
+		return fmt.Errorf("unknown TLS client auth %q, it must be none, optional or require", c.TLS.ClientAuth)
+	}
+	return nil
+}
+
+//Options returns the options of handlers.New for the limits, the trusted proxies and the enabled endpoint groups.
+//The client CAs are given by TLSConfig.
+func (c *Config) Options() []handlers.Option{
+	trusted, _ := handlers.ParseTrustedProxies(strings.Join(c.TrustedProxies, ","))
+	return []handlers.Option{
+		handlers.WithMaxUploadSize(c.MaxBodySize),
+		handlers.WithTrustedProxies(trusted),
+		handlers.WithLimits(c.limits()),
+		handlers.WithGroups(c.Endpoints...),
+	}
+}
+
+//limits returns the limits of the endpoints.
+func (c *Config) limits() handlers.Limits{
+	return handlers.Limits{
+		StreamLines: c.Limits.StreamLines,
+		Delay:       time.Duration(c.Limits.Delay),
+		Bytes:       c.Limits.Bytes,
+		StreamBytes: c.Limits.StreamBytes,
+	}
+}
+
+//Apply sets the limits, the trusted proxies and the enabled endpoint groups of the handlers package.
+//
+//Deprecated: Use Options with handlers.New.
+func (c *Config) Apply(){
+	handlers.MaxUploadSize = c.MaxBodySize
+	handlers.TrustedProxies, _ = handlers.ParseTrustedProxies(strings.Join(c.TrustedProxies, ","))
+	handlers.EndpointLimits = c.limits()
+	handlers.EnabledGroups = c.Endpoints
+}
+
+//Server returns an HTTP server for handler with the address, timeouts and header limit of the configuration.
+func (c *Config) Server(handler http.Handler) *http.Server{
+	return &http.Server{
+		Addr:              c.Address,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(c.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(c.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(c.WriteTimeout),
+		IdleTimeout:       time.Duration(c.IdleTimeout),
+		MaxHeaderBytes:    c.MaxHeaderBytes,
+	}
+}
+
+//ShutdownPolicy returns the graceful shutdown policy of the configuration.
+func (c *Config) ShutdownPolicy() server.Shutdown{
+	return server.Shutdown{Delay: time.Duration(c.ShutdownDelay), Timeout: time.Duration(c.ShutdownTimeout)}
+}
+
+//TLSConfig returns the TLS configuration of the HTTPS listener.
+//It loads the certificate files or, if they are not given, creates or reuses a self-signed certificate in the cert directory.
+//If client certificates are requested, it loads the client CAs into ClientCAs, which /client-cert needs handlers.WithClientCAs for.
+func (c *Config) TLSConfig() (*tls.Config, error){
+	var cert tls.Certificate
+	var err error
+	if c.TLS.CertFile != "" {
+		cert, err = tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+	} else {
+		cert, err = server.LoadOrCreateCertificates(c.TLS.CertDir, c.TLS.Hosts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, ClientAuth: clientAuthTypes[c.TLS.ClientAuth]}
+	if config.ClientAuth == tls.NoClientCert {
+		return config, nil
+	}
+	caFile := c.TLS.ClientCAFile
+	if caFile == "" && c.TLS.CertFile == "" {
+		caFile = filepath.Join(c.TLS.CertDir, server.CAFile)
+	}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificates", caFile)
+		}
+	}
+	return config, nil
+}
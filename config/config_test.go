@@ -0,0 +1,221 @@
+package config
+
+import(
+	"testing"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"github.com/tahasevim/responsiveweb/handlers"
+	"github.com/tahasevim/responsiveweb/server"
+)
+
+func writeConfig(t *testing.T, name, content string) string{
+	dir, err := ioutil.TempDir("","config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func(){ os.RemoveAll(dir) })
+	file := filepath.Join(dir,name)
+	if err := ioutil.WriteFile(file,[]byte(content),0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func setEnv(t *testing.T, key, value string){
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key,value)
+	t.Cleanup(func(){
+		if ok{
+			os.Setenv(key,old)
+		}else{
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestLoadDefault(t *testing.T){
+	c, err := Load("test",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Address != ":8080" || c.Limits.StreamLines != 100 || time.Duration(c.Limits.Delay) != 10*time.Second{
+		t.Errorf("Unexpected default configuration:%+v",c)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T){
+	file := writeConfig(t,"config.yaml",`
+address: ":9000"
+read_timeout: 5s
+max_body_size: 1024
+limits:
+  stream_lines: 10
+  delay: 3s
+endpoints: [methods, anything]
+`)
+	setEnv(t,"RESPONSIVEWEB_READ_TIMEOUT","7s")
+	setEnv(t,"RESPONSIVEWEB_LIMIT_STREAM_LINES","20")
+	c, err := Load("test",[]string{"-config",file,"-limit-stream-lines","30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{
+		name string
+		got interface{}
+		expected interface{}
+	}{
+		{"address from file",c.Address,":9000"},
+		{"read timeout from env",time.Duration(c.ReadTimeout),7*time.Second},
+		{"stream lines from flag",c.Limits.StreamLines,30},
+		{"delay from file",time.Duration(c.Limits.Delay),3*time.Second},
+		{"max body size from file",c.MaxBodySize,int64(1024)},
+		{"write timeout from default",time.Duration(c.WriteTimeout),2*time.Minute},
+		{"endpoints from file",len(c.Endpoints),2},
+	}
+	for _,test := range tests{
+		if test.got != test.expected{
+			t.Errorf("Unexpected %v.\nExpected Result:%v\n Result:%v",test.name,test.expected,test.got)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T){
+	file := writeConfig(t,"config.json",`{"address": "127.0.0.1:9001", "idle_timeout": "1m", "trusted_proxies": ["10.0.0.0/8"]}`)
+	c, err := Load("test",[]string{"-config",file,"-port","9002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Address != ":9002" || time.Duration(c.IdleTimeout) != time.Minute || len(c.TrustedProxies) != 1{
+		t.Errorf("Unexpected configuration:%+v",c)
+	}
+}
+
+func TestLoadErrors(t *testing.T){
+	tests := []struct{
+		name string
+		file string
+		args []string
+	}{
+		{"unknown key","config.yaml: {adress: ':80'}",nil},
+		{"unknown JSON key",`config.json: {"adress": ":80"}`,nil},
+		{"bad duration","config.yaml: {read_timeout: soon}",nil},
+		{"unknown group","",[]string{"-endpoints","methods,nope"}},
+		{"bad proxy","",[]string{"-trusted-proxies","nope"}},
+		{"bad number","",[]string{"-limit-bytes","many"}},
+		{"cert without key","",[]string{"-tls-cert-file","cert.pem"}},
+		{"unknown client auth","",[]string{"-tls-client-auth","maybe"}},
+	}
+	for _,test := range tests{
+		args := test.args
+		if test.file != ""{
+			name := test.file[:len("config.yaml")]
+			args = []string{"-config",writeConfig(t,name,test.file[len(name)+2:])}
+		}
+		if _, err := Load("test",args); err == nil{
+			t.Errorf("%v: invalid configuration was accepted",test.name)
+		}
+	}
+}
+
+func TestApply(t *testing.T){
+	size, limits, groups, proxies := handlers.MaxUploadSize, handlers.EndpointLimits, handlers.EnabledGroups, handlers.TrustedProxies
+	t.Cleanup(func(){
+		handlers.MaxUploadSize, handlers.EndpointLimits, handlers.EnabledGroups, handlers.TrustedProxies = size, limits, groups, proxies
+	})
+	c, err := Load("test",[]string{"-endpoints","methods","-max-body-size","10","-limit-delay","1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Apply()
+	if handlers.MaxUploadSize != 10 || handlers.EndpointLimits.Delay != time.Second{
+		t.Errorf("Configuration was not applied:%v %v",handlers.MaxUploadSize,handlers.EndpointLimits)
+	}
+	for _,route := range handlers.EnabledRoutes(){
+		if route.Group != "methods" && route.Pattern != "/ready"{
+			t.Errorf("Route of disabled group is enabled:%v",route.Pattern)
+		}
+	}
+	server := c.Server(handlers.NewRouter())
+	if server.ReadTimeout != 30*time.Second || server.MaxHeaderBytes != c.MaxHeaderBytes{
+		t.Errorf("Unexpected server:%+v",server)
+	}
+}
+
+func TestOptions(t *testing.T){
+	c, err := Load("test",[]string{"-endpoints","methods","-max-body-size","10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := handlers.New(c.Options()...)
+	tests := []struct{
+		method string
+		path string
+		body string
+		code int
+	}{
+		{"GET","/get","",http.StatusOK},
+		{"POST","/post","longer than 10",http.StatusRequestEntityTooLarge},
+		{"GET","/ip","",http.StatusNotFound},
+	}
+	for _,test := range tests{
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w,httptest.NewRequest(test.method,test.path,strings.NewReader(test.body)))
+		if w.Code != test.code{
+			t.Errorf("%v %v: unexpected result occurred.\nExpected Result:%v\n Result:%v",test.method,test.path,test.code,w.Code)
+		}
+	}
+	//The package variables are not changed.
+	if handlers.MaxUploadSize == 10 || len(handlers.EnabledGroups) != 0{
+		t.Errorf("Package settings were changed:%v %v",handlers.MaxUploadSize,handlers.EnabledGroups)
+	}
+}
+
+func TestTLSConfig(t *testing.T){
+	file := writeConfig(t,"config.yaml","tls: {address: ':8443'}")
+	dir := filepath.Join(filepath.Dir(file),"certs")
+	c, err := Load("test",[]string{"-config",file,"-tls-cert-dir",dir,"-tls-hosts","example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TLS.Address != ":8443" || len(c.TLS.Hosts) != 1{
+		t.Errorf("Unexpected TLS configuration:%+v",c.TLS)
+	}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsConfig.Certificates) != 1{
+		t.Errorf("Unexpected certificates:%v",tlsConfig.Certificates)
+	}
+	if _, err := os.Stat(filepath.Join(dir,server.CAFile)); err != nil {
+		t.Errorf("CA was not written:%v",err)
+	}
+	if tlsConfig.ClientAuth != tls.NoClientCert || tlsConfig.ClientCAs != nil{
+		t.Errorf("Client certificates are requested by default")
+	}
+}
+
+func TestTLSConfigClientAuth(t *testing.T){
+	dir := filepath.Join(filepath.Dir(writeConfig(t,"config.yaml","")),"certs")
+	c, err := Load("test",[]string{"-tls-cert-dir",dir,"-tls-client-auth","require"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAnyClientCert || tlsConfig.ClientCAs == nil || handlers.ClientCAs != nil{
+		t.Errorf("Unexpected client auth:%v %v",tlsConfig.ClientAuth,tlsConfig.ClientCAs)
+	}
+	c.TLS.ClientCAFile = filepath.Join(dir,"missing.pem")
+	if _, err := c.TLSConfig(); err == nil{
+		t.Error("Missing client CA file was accepted")
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/tahasevim/responsiveweb
+
+go 1.18
+
+require (
+	github.com/andybalholm/brotli v1.0.6
+	golang.org/x/net v0.23.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require golang.org/x/text v0.14.0 // indirect
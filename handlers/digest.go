@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//digestRealm is the realm which is sent in every digest challenge.
+const digestRealm = "responsiveweb"
+
+//digestNonceLifetime is the duration after which an issued nonce is reported as stale.
+const digestNonceLifetime = 5 * time.Minute
+
+//digestNonce holds the server side state of an issued nonce.
+type digestNonce struct {
+	opaque  string
+	created time.Time
+	uses    int
+	lastNC  uint64
+}
+
+//maxDigestNonces is how many nonces a nonceStore keeps at most, the oldest one is dropped for a new one beyond it.
+var maxDigestNonces = 10000
+
+//nonceStore keeps the nonces that were issued by DigestAuthHandler and are not expired yet.
+type nonceStore struct {
+	sync.Mutex
+	m map[string]*digestNonce
+	//order is the nonces in the order they were issued, it may still hold nonces which were deleted from m.
+	order []string
+}
+
+//newNonceStore returns an empty nonceStore.
+func newNonceStore() *nonceStore{
+	return &nonceStore{m: make(map[string]*digestNonce)}
+}
+
+//digestNonces are the nonces of the handlers which are not made by New.
+var digestNonces = newNonceStore()
+
+//digestHash returns the hash constructor of the given RFC 7616 algorithm name.
+func digestHash(algorithm string) func() hash.Hash{
+	switch strings.ToUpper(algorithm) {
+	case "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	case "SHA-512-256":
+		return sha512.New512_256
+	}
+	return nil
+}
+
+//digestHex hashes the given parts joined with ':' and returns it in lower case hex.
+func digestHex(h func() hash.Hash, parts ...string) string{
+	d := h()
+	d.Write([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+//randomHex returns n random bytes in hex.
+func randomHex(n int) string{
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//prune drops the expired nonces and then the oldest ones until there is room for a new nonce.
+//The nonces expire in the order they were issued, so only the oldest ones have to be looked at.
+func (s *nonceStore) prune(now time.Time){
+	for len(s.order) > 0 {
+		state, ok := s.m[s.order[0]]
+		if ok && now.Sub(state.created) <= digestNonceLifetime && len(s.order) < maxDigestNonces {
+			return
+		}
+		delete(s.m, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+//newDigestNonce issues a new nonce and opaque pair and stores it in nonces.
+func newDigestNonce(nonces *nonceStore) (string, string){
+	nonce := randomHex(16)
+	opaque := randomHex(16)
+	now := time.Now()
+	nonces.Lock()
+	defer nonces.Unlock()
+	nonces.prune(now)
+	nonces.m[nonce] = &digestNonce{opaque: opaque, created: now}
+	nonces.order = append(nonces.order, nonce)
+	return nonce, opaque
+}
+
+//digestChallenge sets a WWW-Authenticate header with a fresh nonce and writes 401.
+func digestChallenge(w http.ResponseWriter, r *http.Request, qop, algorithm string, stale bool){
+	nonce, opaque := newDigestNonce(stateOf(r).nonces)
+	staleStr := "FALSE"
+	if stale {
+		staleStr = "TRUE"
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="%s", nonce="%s", opaque="%s", algorithm=%s, stale=%s`,
+		digestRealm, qop, nonce, opaque, algorithm, staleStr))
+	http.Error(w, "Unauthorised Attempt", http.StatusUnauthorized)
+}
+
+//parseDigestAuth parses the parameters of a "Digest" Authorization header.
+//It returns nil if the header does not use the Digest scheme.
+func parseDigestAuth(header string) map[string]string{
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Digest ") {
+		return nil
+	}
+	params := make(map[string]string)
+	s := header[7:]
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			var b bytes.Buffer
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			val = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			val = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = val
+	}
+}
+
+//checkDigest verifies the digest response of the request.
+//The first result reports whether the credentials are valid, the second one reports whether the nonce is stale.
+//staleAfter is the number of successful uses after which the nonce becomes stale, 0 means never.
+func checkDigest(r *http.Request, body []byte, qop, user, passwd, algorithm string, staleAfter int) (bool, bool){
+	params := parseDigestAuth(r.Header.Get("Authorization"))
+	if params == nil {
+		return false, false
+	}
+	h := digestHash(algorithm)
+	//RFC 7616 says a missing algorithm parameter means MD5.
+	clientAlgorithm := params["algorithm"]
+	if clientAlgorithm == "" {
+		clientAlgorithm = "MD5"
+	}
+	if params["username"] != user || params["realm"] != digestRealm || params["uri"] != r.URL.RequestURI() ||
+		params["qop"] != qop || !strings.EqualFold(clientAlgorithm, algorithm) {
+		return false, false
+	}
+	nc, err := strconv.ParseUint(params["nc"], 16, 64)
+	if err != nil {
+		return false, false
+	}
+	ha1 := digestHex(h, user, digestRealm, passwd)
+	ha2 := digestHex(h, r.Method, params["uri"])
+	if qop == "auth-int" {
+		ha2 = digestHex(h, r.Method, params["uri"], digestHex(h, string(body)))
+	}
+	expected := digestHex(h, ha1, params["nonce"], params["nc"], params["cnonce"], qop, ha2)
+	if expected != strings.ToLower(params["response"]) {
+		return false, false
+	}
+
+	//The credentials are right from here on, so an unknown or expired nonce is reported as stale.
+	nonces := stateOf(r).nonces
+	nonces.Lock()
+	defer nonces.Unlock()
+	state, ok := nonces.m[params["nonce"]]
+	if !ok || time.Since(state.created) > digestNonceLifetime {
+		delete(nonces.m, params["nonce"])
+		return false, true
+	}
+	if state.opaque != params["opaque"] {
+		return false, false
+	}
+	//A nonce count which does not increase is a replay.
+	if nc <= state.lastNC {
+		return false, true
+	}
+	if staleAfter > 0 && state.uses >= staleAfter {
+		delete(nonces.m, params["nonce"])
+		return false, true
+	}
+	state.lastNC = nc
+	state.uses++
+	return true, false
+}
@@ -11,218 +11,220 @@ import(
 	"encoding/json"
 	"time"
 	"fmt"
-	"math/rand"
+	"io/ioutil"
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"sync/atomic"
 )
 
-
-//GetHandlers adds handlers to the a map and returns it.
-func GetHandlers()map[string]func(http.ResponseWriter,*http.Request){
-	handlerList := make(map[string]func(http.ResponseWriter,*http.Request))
-	handlerList["/"] = IndexHandler
-	handlerList["/ip"] = IpHandler
-	handlerList["/headers"] = HeadersHandler
-	handlerList["/get"] = GetHandler
-	handlerList["/user-agent"] = UseragentHandler
-	handlerList["/uuid"] = UuidHandler
-	handlerList["/post"] = PostHandler
-	handlerList["/delete"] = DeleteHandler
-	handlerList["/put"] = PutHandler
-	handlerList["/anything"] = AnythingHandler
-	handlerList["/anything/"] = AnythingHandler
-	handlerList["/encoding/utf8"] = Utf8Handler
-	handlerList["/gzip"] = GzipHandler
-	handlerList["/deflate"] = DeflateHandler
-	handlerList["/brotli"] = BrotliHandler
-	handlerList["/status/"] = StatusHandler
-	handlerList["/response-headers"] = ResponseHeaderHandler
-	handlerList["/redirect/"] = RedirectMultiHandler
-	handlerList["/redirect-to"] = RedirectToHandler
-	//handlerList["/relative-redirect"] = relativeRedHandler
-	//handlerList["/absolute-redirect"] = absoluteRedHandler
-	handlerList["/cookies"] = CookieHandler
-	handlerList["/cookies/"] = CookieSetDelHandler
-	handlerList["/basic-auth"] = BasicAuthHandler
-	handlerList["/hidden-basic-auth"] = HiddenBasicAuthHandler
-	handlerList["/stream/"] = StreamHandler
-	handlerList["/delay/"] = DelayHandler
-	handlerList["/html"] = HtmlHandler
-	handlerList["/robots.txt"] = RobotsTextHandler
-	handlerList["/deny"] = DenyHandler
-	handlerList["/cache"] = CacheHandler
-	handlerList["/cache/"] = CacheControlHandler
-	handlerList["/bytes/"] = BytesHandler
-	handlerList["/links/"] = LinkHandler
-	handlerList["/image"] = ImageHandler
-	handlerList["/image/png"] = PngHandler
-	handlerList["/image/jpeg"] = JpegHandler
-	handlerList["/image/webp"] = WebpHandler
-	handlerList["/image/svg"] = SvgHandler
-	handlerList["/forms/post"] = FormsHandler
-	handlerList["/xml"] = XmlHandler
-	return handlerList
+//startTime is the time the server started, it is used as the modification time of cached resources.
+var startTime = time.Now()
+
+//Limits are the upper bounds of the parameters of the endpoints.
+type Limits struct {
+	StreamLines int           //lines of /stream/:n
+	Delay       time.Duration //delay of /delay/:n, delay and duration of /drip and duration of /range/:n
+	Bytes       int           //bytes of /bytes/:n and /range/:n
+	StreamBytes int           //bytes of /stream-bytes/:n and /drip
+}
+
+//EndpointLimits are the limits which the endpoints apply.
+//
+//Deprecated: Use New with WithLimits.
+var EndpointLimits = defaultLimits
+
+//Routes returns the route table of all endpoints with their descriptions, example URLs, response media types and groups.
+func Routes() []Route{
+	get := []string{"GET"}
+	return []Route{
+		{get,"/",IndexHandler,"Returns home page.","/","text/html","meta"},
+		{get,"/explorer",ExplorerHandler,"Returns a page to send requests to any endpoint and inspect the responses.","/explorer","text/html","meta"},
+		{get,"/explorer/{file}",ExplorerHandler,"Returns a script or style sheet of the explorer page.","/explorer/explorer.js","text/*","meta"},
+		{get,"/routes",RoutesHandler,"Returns all endpoints in JSON format.","/routes","application/json","meta"},
+		{get,"/openapi.json",OpenAPIHandler,"Returns the OpenAPI 3 specification of the endpoints in JSON format.","/openapi.json","application/json","meta"},
+		{get,"/openapi.yaml",OpenAPIHandler,"Returns the OpenAPI 3 specification of the endpoints in YAML format.","/openapi.yaml","application/yaml","meta"},
+		{get,"/ready",ReadyHandler,"Returns 200 while the server accepts requests and 503 once it is shutting down.","/ready","application/json","meta"},
+		{get,"/tls-info",TLSInfoHandler,"Returns the TLS version, cipher suite, SNI server name and ALPN protocol of the connection.","/tls-info","application/json","tls"},
+		{get,"/client-cert",ClientCertHandler,"Returns the subject, issuer, SANs, serial, validity and fingerprints of the client certificate chain, 403 if it cannot be verified.","/client-cert","application/json","tls"},
+		{get,"/http2",HTTP2Handler,"Returns the protocol, stream ID and SETTINGS of the HTTP/2 connection.","/http2","application/json","http2"},
+		{get,"/ip",IpHandler,"Returns origin ip.","/ip","application/json","inspection"},
+		{get,"/uuid",UuidHandler,"Returns UUID.","/uuid","application/json","dynamic"},
+		{get,"/user-agent",UseragentHandler,"Returns user-agent.","/user-agent","application/json","inspection"},
+		{get,"/headers",HeadersHandler,"Returns headers map.","/headers","application/json","inspection"},
+		{get,"/get",GetHandler,"Returns GET data.","/get","application/json","methods"},
+		{[]string{"POST"},"/post",PostHandler,"Returns POST data.","/post","application/json","methods"},
+		{[]string{"PUT"},"/put",PutHandler,"Returns PUT data.","/put","application/json","methods"},
+		{[]string{"DELETE"},"/delete",DeleteHandler,"Returns DELETE data.","/delete","application/json","methods"},
+		{[]string{"PATCH"},"/patch",PatchHandler,"Returns PATCH data.","/patch","application/json","methods"},
+		{anyMethod,"/anything",AnythingHandler,"Returns request data, including method used.","/anything","application/json","anything"},
+		{anyMethod,"/anything/{anything:path}",AnythingHandler,"Returns request data, including the URL.","/anything/foo/bar","application/json","anything"},
+		{get,"/encoding/utf8",Utf8Handler,"Returns page containing UTF-8 data.","/encoding/utf8","text/html","formats"},
+		{get,"/gzip",GzipHandler,"Returns gzip-encoded data.","/gzip","application/json","formats"},
+		{get,"/deflate",DeflateHandler,"Returns deflate-encoded data.","/deflate","application/json","formats"},
+		{get,"/brotli",BrotliHandler,"Returns brotli-encoded data.","/brotli","application/json","formats"},
+		{anyMethod,"/status/{codes}",StatusHandler,"Returns given HTTP Status Code or a random one of comma separated codes with optional weights.","/status/418","","status"},
+		{[]string{"GET","POST"},"/response-headers",ResponseHeaderHandler,"Returns given response headers.","/response-headers?key=value","application/json","response"},
+		{get,"/redirect/{n:int}",RedirectMultiHandler,"302 Redirects n times.","/redirect/6","","redirects"},
+		{get,"/redirect-to",RedirectToHandler,"302 or status_code Redirects to the url URL.","/redirect-to?url=/get&status_code=307","","redirects"},
+		{get,"/relative-redirect/{n:int}",RelativeRedirectHandler,"302 Relative redirects n times.","/relative-redirect/6","","redirects"},
+		{get,"/absolute-redirect/{n:int}",AbsoluteRedirectHandler,"302 Absolute redirects n times.","/absolute-redirect/6","","redirects"},
+		{get,"/cookies",CookieHandler,"Returns cookie data.","/cookies","application/json","cookies"},
+		{get,"/cookies/set",CookieSetDelHandler,"Sets one or more simple cookies.","/cookies/set?name=value","application/json","cookies"},
+		{get,"/cookies/delete",CookieSetDelHandler,"Deletes one or more simple cookies.","/cookies/delete?name","","cookies"},
+		{get,"/basic-auth/{user}/{passwd}",BasicAuthHandler,"Challenges HTTPBasic Auth.","/basic-auth/user/passwd","application/json","auth"},
+		{get,"/hidden-basic-auth/{user}/{passwd}",HiddenBasicAuthHandler,"404'd BasicAuth.","/hidden-basic-auth/user/passwd","application/json","auth"},
+		{get,"/digest-auth/{qop}/{user}/{passwd}",DigestAuthHandler,"Challenges HTTP Digest Auth with MD5.","/digest-auth/auth/user/passwd","application/json","auth"},
+		{get,"/digest-auth/{qop}/{user}/{passwd}/{algorithm}",DigestAuthHandler,"Challenges HTTP Digest Auth with MD5, SHA-256 or SHA-512-256.","/digest-auth/auth/user/passwd/SHA-256","application/json","auth"},
+		{get,"/digest-auth/{qop}/{user}/{passwd}/{algorithm}/{stale_after}",DigestAuthHandler,"Challenges HTTP Digest Auth, the nonce is stale after stale_after requests.","/digest-auth/auth/user/passwd/MD5/never","application/json","auth"},
+		{get,"/stream/{n:int}",StreamHandler,"Streams min(n, 100) lines.","/stream/20","application/json","dynamic"},
+		{get,"/delay/{n:int}",DelayHandler,"Delays responding for min(n, 10) seconds.","/delay/3","application/json","dynamic"},
+		{get,"/drip",DripHandler,"Drips data over a duration after an optional initial delay, then (optionally) returns with the given status code.","/drip?numbytes=10&duration=2&delay=1&code=200","application/octet-stream","dynamic"},
+		{get,"/range/{n:int}",RangeHandler,"Streams n bytes, and allows specifying a Range header to select a subset of the data. Accepts a chunk_size and request duration parameter.","/range/1024","application/octet-stream","dynamic"},
+		{get,"/html",HtmlHandler,"Renders an HTML Page.","/html","text/html","formats"},
+		{get,"/robots.txt",RobotsTextHandler,"Returns some robots.txt rules.","/robots.txt","text/plain","formats"},
+		{get,"/deny",DenyHandler,"Denied by robots.txt file.","/deny","text/plain","formats"},
+		{get,"/cache",CacheHandler,"Returns 200 unless an If-Modified-Since or If-None-Match header matches, when it returns a 304.","/cache","application/json","response"},
+		{get,"/cache/{n:int}",CacheControlHandler,"Sets a Cache-Control header for n seconds.","/cache/60","application/json","response"},
+		{get,"/etag/{etag}",EtagHandler,"Assumes the resource has the given etag and responds to If-None-Match header with a 200 or 304 and If-Match with a 200 or 412 as appropriate.","/etag/etag","application/json","response"},
+		{get,"/bytes/{n:int}",BytesHandler,"Generates n random bytes of binary data, the same bytes for the same seed.","/bytes/1024?seed=42","application/octet-stream","dynamic"},
+		{get,"/stream-bytes/{n:int}",StreamBytesHandler,"Streams n random bytes of binary data in chunked encoding.","/stream-bytes/1024?seed=42&chunk_size=256","application/octet-stream","dynamic"},
+		{get,"/links/{n:int}",LinkHandler,"Returns page containing n HTML links.","/links/10","text/html","dynamic"},
+		{get,"/links/{n:int}/{offset:int}",LinkHandler,"Returns page containing n HTML links, the one at offset is not linked.","/links/10/0","text/html","dynamic"},
+		{get,"/image",ImageHandler,"Returns an image in the format chosen by the Accept header.","/image","image/*","images"},
+		{get,"/image/png",PngHandler,"Returns a PNG image.","/image/png","image/png","images"},
+		{get,"/image/jpeg",JpegHandler,"Returns a JPEG image.","/image/jpeg","image/jpeg","images"},
+		{get,"/image/webp",WebpHandler,"Returns a WEBP image.","/image/webp","image/webp","images"},
+		{get,"/image/svg",SvgHandler,"Returns a SVG image.","/image/svg","image/svg+xml","images"},
+		{get,"/forms/post",FormsHandler,"HTML form that submits to /post.","/forms/post","text/html","formats"},
+		{get,"/xml",XmlHandler,"Returns some XML.","/xml","application/xml","formats"},
+	}
 }
 
 //IpHandler handles a GET request and sends a response in JSON format that contains IP address of client which made request.
 func IpHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	jsonData := getAllJSONdata(r,"origin")	
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //IndexHandler handles a GET request and sends a HTML page that contains links of endpoints.
 func IndexHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
-	templates.IndexTemplate.ExecuteTemplate(w, "index", nil)
+	templates.IndexTemplate.ExecuteTemplate(w, "index", stateOf(r).enabledRoutes())
+}
+
+//RoutesHandler handles a GET request and sends all endpoints with their methods, descriptions and example URLs in JSON format.
+func RoutesHandler(w http.ResponseWriter, r *http.Request){
+	writeJSON(w,http.StatusOK,stateOf(r).enabledRoutes())
 }
 //HeadersHandler handles a GET request and sends a response in JSON format that contains header of the coming request.
 func HeadersHandler(w http.ResponseWriter,r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	jsonData := getAllJSONdata(r,"headers")	
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
-//GetHandler handles a GET request and sends a response in JSON format that contains args,IP,headers,url of the coming request.
+//GetHandler handles a GET or HEAD request and sends a response in JSON format that contains args,IP,headers,url of the coming request.
 func GetHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
-	jsonData := getAllJSONdata(r,"args","headers","origin","url")
-	w.Write(makeJSONresponse(jsonData))
+	jsonData := getAllJSONdata(r,"args","headers","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //UseragentHandler handles a GET request and sends a response in JSON format that contains user-agent of the coming request.
 func UseragentHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	jsonData := getAllJSONdata(r,"user-agent")	
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //UuidHandler handles a GET request and sends a response in JSON format that contains uuid (Universally unique identifier).
 //uuid is obtained by operating system's "uuidgen" tool.
 func UuidHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET" {
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	jsonData := getAllJSONdata(r,"uuid")	
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
-//PostHandler handles a POST request and sends a response in JSON format that contains args,data,files,form,headers,IP,url of the coming request.
+//PostHandler handles a POST request and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url of the coming request.
 func PostHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "POST" {
-		http.Error(w,"Method Not Allowed",405)
+	if !readBody(w,r){
 		return
 	}
-	jsonData := getAllJSONdata(r ,"args","data","files","form","headers","json","origin","url")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r ,"args","data","files","uploads","form","headers","json","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
-//DeleteHandler handles a DELETE request and sends a response in JSON format that contains args,data,files,form,headers,IP,url of the coming request.
+//DeleteHandler handles a DELETE request and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url of the coming request.
 func DeleteHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "DELETE" {
-		http.Error(w,"Method Not Allowed",405)
+	if !readBody(w,r){
 		return
 	}
-	jsonData := getAllJSONdata(r ,"args","data","files","form","headers","json","origin","url")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r ,"args","data","files","uploads","form","headers","json","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
-//PutHandler handles a PUT request and sends a response in JSON format that contains args,data,files,form,headers,IP,url of the coming request.
+//PutHandler handles a PUT request and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url of the coming request.
 func PutHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "PUT" {
-		http.Error(w,"Method Not Allowed",405)
+	if !readBody(w,r){
 		return
 	}
-	jsonData := getAllJSONdata(r ,"args","data","files","form","headers","json","origin","url")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r ,"args","data","files","uploads","form","headers","json","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
-//patchHandler handles a PATCH request and sends a response in JSON format that contains args,data,files,form,headers,IP,url of the coming request.
-func patchHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "PATCH" {
-		http.Error(w,"Method Not Allowed",405)
+//PatchHandler handles a PATCH request and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url of the coming request.
+func PatchHandler(w http.ResponseWriter, r *http.Request){
+	if !readBody(w,r){
 		return
 	}
-	jsonData := getAllJSONdata(r ,"args","data","files","form","headers","json","origin","url")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r ,"args","data","files","uploads","form","headers","json","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
-//AnythingHandler can handle any type of request and sends a response in JSON format that contains args,data,files,form,headers,IP,url,method of the coming request.
+//AnythingHandler handles GET,POST,PUT,PATCH,DELETE and TRACE requests and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url,method of the coming request.
 func AnythingHandler(w http.ResponseWriter, r *http.Request){
-	jsonData := getAllJSONdata(r ,"args","data","files","form","headers","json","origin","url","method")
-	w.Write(makeJSONresponse(jsonData))
+	if !readBody(w,r){
+		return
+	}
+	jsonData := getAllJSONdata(r ,"args","data","files","uploads","form","headers","json","origin","protocol","url","method")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //Utf8Handler handles a GET request and sends a UTF8 encoded template that contains a lot of different UTF8 encoded characters.
 func Utf8Handler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	templates.Utf8Template.ExecuteTemplate(w,"utf8",nil)
 }
 
 //GzipHandler handles a GET request and sends a response in JSON format that contains gzipped,headers,method,IP of the coming request.
+//The response body is gzip-encoded.
 func GzipHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
-	jsonData := getAllJSONdata(r,"gzipped","headers","method","origin")
-	w.Write(makeJSONresponse(jsonData))
-	
+	jsonData := getAllJSONdata(r,"gzipped","headers","method","origin","protocol")
+	writeEncoded(w,"gzip",makeJSONresponse(jsonData))
 }
 
-//BrotliHandler handles a GET request and sends a response in JSON format that contains gzipped,headers,method,IP of the coming request.
+//BrotliHandler handles a GET request and sends a response in JSON format that contains brotli,headers,method,IP of the coming request.
+//The response body is brotli-encoded.
 func BrotliHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
-	jsonData := getAllJSONdata(r,"brotli","headers","method","origin")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r,"brotli","headers","method","origin","protocol")
+	writeEncoded(w,"br",makeJSONresponse(jsonData))
 }
 
-//DeflateHandler handles a GET request and sends a response in JSON format that contains gzipped,headers,method,IP of the coming request.
+//DeflateHandler handles a GET request and sends a response in JSON format that contains deflated,headers,method,IP of the coming request.
+//The response body is deflate-encoded.
 func DeflateHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
-	jsonData := getAllJSONdata(r,"deflated","headers","method","origin")
-	w.Write(makeJSONresponse(jsonData))	
+	jsonData := getAllJSONdata(r,"deflated","headers","method","origin","protocol")
+	writeEncoded(w,"deflate",makeJSONresponse(jsonData))
 }
 
-//StatusHandler can handle any type of request and redirects the coming request to the that status's url.
+//StatusHandler can handle any type of request and returns the given status code for /status/:code.
+//Several comma separated codes with optional weights (/status/200:0.8,500:0.2) can be given, then one of them is chosen randomly.
 func StatusHandler(w http.ResponseWriter, r *http.Request){
-	stat,_ := strconv.ParseInt(r.URL.Path[len("/status/"):],10,64)
-	if int(stat)==0{
-		w.WriteHeader(418)
-		http.Redirect(w,r,"/status/418",418)
+	code, err := chooseStatus(pathParam(r,"codes"))
+	if err != nil {
+		http.Error(w,"Invalid status code",http.StatusBadRequest)
+		return
 	}
-	http.Redirect(w,r,"/status/"+string(stat),int(stat))
+	writeStatus(w,code)
 }
 
 //ResponseHeaderHandler handles a GET or POST request and sends a response in JSON format.
 //It prepares a JSON response from url of the coming request.
 func ResponseHeaderHandler(w http.ResponseWriter, r *http.Request){
-	if !(r.Method == "GET" || r.Method == "POST"){
-		http.Error(w,"Method Not Allowed",405)
-		return
-	}
 	jsonData := jsonMap{}
 	for key,value := range r.URL.Query(){
 		if len(value) == 1{
@@ -232,39 +234,33 @@ func ResponseHeaderHandler(w http.ResponseWriter, r *http.Request){
 		}
 	}
 	jsonData["Content-Type"] = "application/json"
-	w.Write(makeJSONresponse(jsonData))	
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //RedirectMultiHandler handles a GET request and redirects the coming request n times.
+//Every hop redirects to /redirect/:n-1 and the last one to /get.
+//If absolute=true is given the chain continues with absolute URLs through /absolute-redirect/:n.
 func RedirectMultiHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	ntime, err := strconv.ParseInt(r.URL.Path[len("/redirect/"):],10,64)
-	if int(ntime)<0{
-		w.Write([]byte("Invalid n"))
-		return
-	}
-	if int(ntime)== 0{
-		http.Redirect(w,r,"/get",302)
-	}
-	if err != nil {
-		w.Write([]byte("Invalid n"))
+	if r.URL.Query().Get("absolute") == "true"{
+		redirectChain(w,r,"/absolute-redirect/",true)
 		return
 	}
-	for i:=0;i<int(ntime);i++{
-		http.Redirect(w,r,"/get",302)	
-	}
+	redirectChain(w,r,"/redirect/",false)
+}
+
+//RelativeRedirectHandler handles a GET request and redirects the coming request n times with relative Location headers.
+func RelativeRedirectHandler(w http.ResponseWriter, r *http.Request){
+	redirectChain(w,r,"/relative-redirect/",false)
+}
+
+//AbsoluteRedirectHandler handles a GET request and redirects the coming request n times with absolute Location headers.
+func AbsoluteRedirectHandler(w http.ResponseWriter, r *http.Request){
+	redirectChain(w,r,"/absolute-redirect/",true)
 }
 
 //RedirectToHandler handles a GET request and redirects the coming request to the given url parameter.
 func RedirectToHandler(w http.ResponseWriter, r *http.Request){
 	var stat int
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	url := r.URL.Query().Get("url")
 	statstr, _ := strconv.ParseInt(r.URL.Query().Get("status_code"),10,64)
 	if statstr == 0{
@@ -277,29 +273,21 @@ func RedirectToHandler(w http.ResponseWriter, r *http.Request){
 
 //CookieHandler handles a GET request and sends a response in JSON format that contains cookies.
 func CookieHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	jsonData := jsonMap{}
 	cookieMap := jsonMap{}
 	for _,cookie := range r.Cookies(){
 		cookieMap[cookie.Name] = cookie.Value
 	}
 	jsonData["cookies"] = cookieMap
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //CookieSetDelHandler handles a GET request and sends a response in JSON format that contains cookies.
 //It sets or deletes cookies according to given url (/set or /delete).
 func CookieSetDelHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	if r.URL.Path == "/cookies/set"{
 		jsonData := setCooki(w,r)
-		w.Write(makeJSONresponse(jsonData))
+		writeJSON(w,http.StatusOK,jsonData)
 		return	
 	}
 	if r.URL.Path == "/cookies/delete"{
@@ -310,77 +298,86 @@ func CookieSetDelHandler(w http.ResponseWriter, r *http.Request){
 	http.Redirect(w,r,"/cookies",302)
 }
 
-//BasicAuthHandler handles a GET request and sends a response in JSON format.
+//BasicAuthHandler handles a GET request and sends a response in JSON format for /basic-auth/:user/:passwd.
 //It recieves password and username from client and checks that it is valid or not.
 func BasicAuthHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	jsonData := jsonMap{}
-	jsonData = getAllJSONdata(r,"authenticated","user")
-	if len(r.URL.String())==12{
-		w.Write(makeJSONresponse(jsonData))
-		return
-	}
-	w.Header().Set("WWW-Authenticate", `Basic realm="localhost:8080"`)//localhost
-	if strings.Count(r.URL.String()[len("/basic-auth/"):],"/") != 1 {
-		http.Error(w,"Not Found",http.StatusNotFound)
-		return
-	}
-	userAndpasswd := r.URL.String()[len("/basic-auth/"):]
-	user := userAndpasswd[:strings.Index(userAndpasswd,"/")]
-	pass := userAndpasswd[strings.Index(userAndpasswd,"/")+1:]
+	user, pass := pathParam(r,"user"), pathParam(r,"passwd")
 	if !check(user,pass,r){
+		w.Header().Set("WWW-Authenticate", `Basic realm="localhost:8080"`)//localhost
 		http.Error(w,"Unauthorised Attempt",http.StatusUnauthorized)
 		return
 	}
-
-	w.Write(makeJSONresponse(jsonData))
+	jsonData := getAllJSONdata(r,"authenticated","user")
+	writeJSON(w,http.StatusOK,jsonData)
 	log.Println("User logged in:",user)
-	
 }
-//HiddenBasicAuthHandler handles a GET request and sends a response in JSON format.
-//It recieves password and username from client and checks that it is valid or not.
+
+//HiddenBasicAuthHandler handles a GET request and sends a response in JSON format for /hidden-basic-auth/:user/:passwd.
+//It recieves password and username from client and returns 404 status code instead of 401 if they are not valid.
 func HiddenBasicAuthHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
+	user, pass := pathParam(r,"user"), pathParam(r,"passwd")
+	if !check(user,pass,r){
+		http.Error(w,"Not Found",http.StatusNotFound)
+		return
+	} 
+	jsonData := getAllJSONdata(r,"authenticated","user")
+	writeJSON(w,http.StatusOK,jsonData)
+	log.Println("User logged in:",user)
+}
+
+//DigestAuthHandler handles a GET request and sends a response in JSON format.
+//It challenges the client with HTTP Digest Auth (RFC 7616) for /digest-auth/:qop/:user/:passwd/:algorithm/:stale_after.
+//qop is "auth" or "auth-int", algorithm is MD5 (default), SHA-256 or SHA-512-256 and
+//stale_after is the number of requests after which the nonce is reported as stale ("never" by default).
+func DigestAuthHandler(w http.ResponseWriter, r *http.Request){
+	qop, user, passwd := pathParam(r,"qop"), pathParam(r,"user"), pathParam(r,"passwd")
+	algorithm := "MD5"
+	if a := pathParam(r,"algorithm"); a != ""{
+		algorithm = strings.ToUpper(a)
+	}
+	staleAfter := 0
+	if sa := pathParam(r,"stale_after"); sa != "" && sa != "never"{
+		n, err := strconv.Atoi(sa)
+		if err != nil || n < 0 {
+			http.Error(w,"Invalid stale_after",http.StatusBadRequest)
+			return
+		}
+		staleAfter = n
 	}
-	w.Header().Set("WWW-Authenticate", `Basic realm="localhost:8080"`)	
-	if len(r.URL.String())==12 || strings.Count(r.URL.String()[len("/basic-auth/"):],"/") != 1 {
+	if (qop != "auth" && qop != "auth-int") || digestHash(algorithm) == nil{
 		http.Error(w,"Not Found",http.StatusNotFound)
 		return
 	}
-	userAndpasswd := r.URL.String()[len("/basic-auth/"):]
-	user := userAndpasswd[:strings.Index(userAndpasswd,"/")]
-	pass := userAndpasswd[strings.Index(userAndpasswd,"/")+1:]
-	if !check(user,pass,r){
-		http.Error(w,"Unauthorised Attempt",http.StatusUnauthorized)
+	var body []byte
+	if r.Body != nil{
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	ok, stale := checkDigest(r,body,qop,user,passwd,algorithm,staleAfter)
+	if !ok{
+		digestChallenge(w,r,qop,algorithm,stale)
 		return
-	} 
-	http.Error(w,"Not Found",http.StatusNotFound)		
+	}
+	jsonData := jsonMap{}
+	jsonData["authenticated"] = true
+	jsonData["user"] = user
+	writeJSON(w,http.StatusOK,jsonData)
+	log.Println("User logged in:",user)
 }
 
 //StreamHandler handles a GET request and sends a response in JSON format that contains url,args,headers,IP of the coming request.
 //It sends response n times.
 func StreamHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	var n int
-	nparam, err := strconv.ParseInt(r.URL.String()[len("/stream/"):],10,64)
+	limits := *stateOf(r).limits
+	n := intParam(r,"n")
 	switch{
-	case err != nil:
-		n = 20
-	case int(nparam)>100:
-		n = 100
-	case int(nparam)<=100:
-		n = int(nparam)
+	case n>limits.StreamLines:
+		n = limits.StreamLines
+	case n<0:
+		n = 0
 	}
 	jsonData := jsonMap{}
-	jsonData = getAllJSONdata(r,"url","args","headers","origin")
+	jsonData = getAllJSONdata(r,"url","args","headers","origin","protocol")
+	w.Header().Set("Content-Type","application/json")
 	for i:=0;i<n;i++{
 		jsonResp,_:= json.Marshal(jsonData)
 		w.Write(jsonResp)
@@ -388,54 +385,167 @@ func StreamHandler(w http.ResponseWriter, r *http.Request){
 	}
 }
 
-//DelayHandler handles a GET request and sends a response in JSON format that contains args,data,files,form,headers,IP,url of the coming request.
+//DelayHandler handles a GET request and sends a response in JSON format that contains args,data,files,uploads,form,headers,IP,url of the coming request.
 //It sends response with a delayed time according to given n.
 func DelayHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
+	if !readBody(w,r){
+		return
 	}
-	var n int
-	nparam, err := strconv.ParseInt(r.URL.String()[len("/delay/"):],10,64)
+	limits := *stateOf(r).limits
+	delay := time.Second * time.Duration(intParam(r,"n"))
 	switch{
-	case err != nil:
-		n = 3
-	case int(nparam)>10:
-		n = 10
-	case int(nparam)<=10:
-		n = int(nparam)
-	}
-	time.Sleep(time.Second * time.Duration(n))
+	case delay>limits.Delay:
+		delay = limits.Delay
+	case delay<0:
+		delay = 0
+	}
+	if !sleepContext(r,delay){
+		return
+	}
 	jsonData := jsonMap{}
-	jsonData = getAllJSONdata(r,"args","data","files","form","headers","origin","url")
-	w.Write(makeJSONresponse(jsonData))
+	jsonData = getAllJSONdata(r,"args","data","files","form","headers","origin","protocol","url")
+	writeJSON(w,http.StatusOK,jsonData)
+}
+
+//DripHandler handles a GET request and drips numbytes bytes over duration seconds after waiting delay seconds.
+//Both delay and duration are capped by the Delay limit.
+//The response is sent with the given status code and it stops as soon as the client goes away.
+func DripHandler(w http.ResponseWriter, r *http.Request){
+	limits := *stateOf(r).limits
+	numbytes, err := queryInt(r,"numbytes",10)
+	if err != nil || numbytes <= 0 || numbytes > limits.StreamBytes{
+		http.Error(w,"Invalid numbytes",http.StatusBadRequest)
+		return
+	}
+	duration, err := queryFloat(r,"duration",2)
+	if err != nil || duration < 0{
+		http.Error(w,"Invalid duration",http.StatusBadRequest)
+		return
+	}
+	if duration > limits.Delay.Seconds(){
+		duration = limits.Delay.Seconds()
+	}
+	delay, err := queryFloat(r,"delay",0)
+	if err != nil || delay < 0{
+		http.Error(w,"Invalid delay",http.StatusBadRequest)
+		return
+	}
+	if delay > limits.Delay.Seconds(){
+		delay = limits.Delay.Seconds()
+	}
+	code, err := queryInt(r,"code",200)
+	if err != nil || code < 100 || code > 599{
+		http.Error(w,"Invalid code",http.StatusBadRequest)
+		return
+	}
+	if !sleepContext(r,time.Duration(delay*float64(time.Second))){
+		return
+	}
+	w.Header().Set("Content-Type","application/octet-stream")
+	w.Header().Set("Content-Length",strconv.Itoa(numbytes))
+	w.WriteHeader(code)
+	flusher, _ := w.(http.Flusher)
+	pause := time.Duration(duration*float64(time.Second)) / time.Duration(numbytes)
+	for i:=0;i<numbytes;i++{
+		if i > 0 && !sleepContext(r,pause){
+			log.Printf("drip cancelled after %d of %d bytes",i,numbytes)
+			return
+		}
+		w.Write([]byte("*"))
+		if flusher != nil{
+			flusher.Flush()
+		}
+	}
+}
+
+//RangeHandler handles a GET request and sends n bytes of deterministic content for /range/:n.
+//It supports single, suffix and multiple ranges through Range and If-Range headers,
+//the whole content is sent if there are too many ranges or they add up to more than it.
+//If duration is given the body is sent in chunk_size pieces spread over duration seconds, which is capped by the Delay limit.
+func RangeHandler(w http.ResponseWriter, r *http.Request){
+	limits := *stateOf(r).limits
+	n := intParam(r,"n")
+	if n <= 0 || n > limits.Bytes{
+		http.Error(w,fmt.Sprintf("number of bytes must be in the range (0, %d]",limits.Bytes),http.StatusNotFound)
+		return
+	}
+	chunkSize, err := queryInt(r,"chunk_size",10*1024)
+	if err != nil || chunkSize <= 0{
+		http.Error(w,"Invalid chunk_size",http.StatusBadRequest)
+		return
+	}
+	duration, err := queryFloat(r,"duration",0)
+	if err != nil || duration < 0{
+		http.Error(w,"Invalid duration",http.StatusBadRequest)
+		return
+	}
+	if duration > limits.Delay.Seconds(){
+		duration = limits.Delay.Seconds()
+	}
+	content := make([]byte,n)
+	for i := range content{
+		content[i] = byte('a'+i%26)
+	}
+	etag := fmt.Sprintf(`"range%d"`,n)
+	w.Header().Set("Accept-Ranges","bytes")
+	w.Header().Set("ETag",etag)
+
+	var ranges []byteRange
+	if ifRange := r.Header.Get("If-Range"); ifRange == "" || ifRange == etag{
+		ranges, err = parseByteRanges(r.Header.Get("Range"),n)
+	}
+	if err == errUnsatisfiableRange{
+		w.Header().Set("Content-Range",fmt.Sprintf("bytes */%d",n))
+		http.Error(w,"Range Not Satisfiable",http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	body := content
+	status := http.StatusOK
+	switch{
+	case len(ranges) == 1:
+		body = content[ranges[0].start:ranges[0].end+1]
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Type","application/octet-stream")
+		w.Header().Set("Content-Range",ranges[0].contentRange(n))
+	case len(ranges) > 1:
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for _,br := range ranges{
+			part, _ := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":{"application/octet-stream"},
+				"Content-Range":{br.contentRange(n)},
+			})
+			part.Write(content[br.start:br.end+1])
+		}
+		mw.Close()
+		body = buf.Bytes()
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Type","multipart/byteranges; boundary="+mw.Boundary())
+	default:
+		w.Header().Set("Content-Type","application/octet-stream")
+	}
+	w.Header().Set("Content-Length",strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	if r.Method == "HEAD"{
+		return
+	}
+	writePaced(w,r,body,chunkSize,time.Duration(duration*float64(time.Second)))
 }
 
 //HtmlHandler handles a GET request and sends a sample HTML template.
 func HtmlHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	templates.SampleTemplate.ExecuteTemplate(w,"sample",nil)
 }
 
 //RobotsTextHandler handles a GET request and sends a message that contains some robots.txt rules.
 func RobotsTextHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	w.Write([]byte("User-agent: *\nDisallow: /deny"))
 
 }
 
 //DenyHandler handles a GET request and sends a message which recites that denied by robots.txt rules.
 func DenyHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	w.Write([]byte(` 
 	  .-''''''-.
         .' _      _ '.
@@ -448,90 +558,112 @@ func DenyHandler(w http.ResponseWriter, r *http.Request){
      YOU SHOULDN'T BE HERE`))
 }
 
-//ImageHandler handles a GET request and redirects it to "https://httpbin.org/image". 
+//ImageHandler handles a GET request and sends an image in the format which is chosen according to the Accept header.
+//If none of the formats is acceptable it returns 406 status code.
 func ImageHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
+	w.Header().Add("Vary","Accept")
+	img, ok := negotiateImage(r.Header.Get("Accept"))
+	if !ok{
+		writeStatus(w,http.StatusNotAcceptable)
+		return
 	}
-	http.Redirect(w,r,"https://httpbin.org/image",http.StatusOK)
+	writeImage(w,img)
 }
 
-//PngHandler handles a GET request and redirects it to "https://httpbin.org/image/png". 
+//PngHandler handles a GET request and sends a PNG image.
 func PngHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	http.Redirect(w,r,"https://httpbin.org/image/png",http.StatusOK)
+	writeImage(w,imageFormats[0])
 }
 
-//JpegHandler handles a GET request and redirects it to "https://httpbin.org/image/jpeg". 
+//JpegHandler handles a GET request and sends a JPEG image.
 func JpegHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	http.Redirect(w,r,"https://httpbin.org/image/jpeg",http.StatusOK)
+	writeImage(w,imageFormats[1])
 }
 
-//WebpHandler handles a GET request and redirects it to "https://httpbin.org/image/webp". 
+//WebpHandler handles a GET request and sends a WEBP image.
 func WebpHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	http.Redirect(w,r,"https://httpbin.org/image/webp",http.StatusOK)
+	writeImage(w,imageFormats[2])
 }
 
-//SvgHandler handles a GET request and redirects it to "https://httpbin.org/image/svg". 
+//SvgHandler handles a GET request and sends a SVG image.
 func SvgHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	http.Redirect(w,r,"https://httpbin.org/image/svg",http.StatusOK)
+	writeImage(w,imageFormats[3])
 }
 
 //FormsHandler handles a GET request and a sends sample form template.
 func FormsHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	templates.FormsTemplate.ExecuteTemplate(w,"forms",nil)
 }
 
+//ExplorerHandler handles a GET request and sends the API explorer page for /explorer or one of its assets for /explorer/:file.
+func ExplorerHandler(w http.ResponseWriter, r *http.Request){
+	name := pathParam(r,"file")
+	if name == ""{
+		name = "explorer.html"
+	}
+	data, err := templates.ExplorerFiles.ReadFile("explorer/"+name)
+	if err != nil{
+		http.NotFound(w,r)
+		return
+	}
+	http.ServeContent(w,r,name,startTime,bytes.NewReader(data))
+}
+
+//notReady is set to 1 when the server starts shutting down.
+var notReady int32
+
+//SetReady sets the readiness which is reported by /ready of the router and the package handlers, see Handler.SetReady for those of New.
+func SetReady(ready bool){
+	setReady(&notReady,ready)
+}
+
+//setReady stores the readiness in notReady.
+func setReady(notReady *int32, ready bool){
+	if ready{
+		atomic.StoreInt32(notReady,0)
+	}else{
+		atomic.StoreInt32(notReady,1)
+	}
+}
+
+//ReadyHandler handles a GET request and sends the readiness of the server in JSON format.
+//It answers 503 once the server is shutting down so that load balancers stop sending requests before the listener is closed.
+func ReadyHandler(w http.ResponseWriter, r *http.Request){
+	ready := atomic.LoadInt32(stateOf(r).notReady) == 0
+	code := http.StatusOK
+	if !ready{
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w,code,jsonMap{"ready":ready})
+}
+
 //XmlHandler handles a GET request and sends sample XML template.
 func XmlHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
 	w.Header().Set("Content-Type","application/xml")
 	templates.XmlTemplate.ExecuteTemplate(w,"xml",nil)
 }
 
-//LinkHandler handles a GET request and sends n numbers link.
+//LinkHandler handles a GET request and sends n numbers link for /links/:n or /links/:n/:offset.
+//The link of the given offset is not linked.
 func LinkHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	var n int
-	strArr := strings.Split(r.URL.String(),"/")
-	nparam,err := strconv.ParseInt(strArr[2],10,64)
+	n := intParam(r,"n")
 	switch{
-	case err != nil:
-		n = 10
-	case int(nparam)>200:
+	case n>200:
 		n = 200
-	case int(nparam)<=200:
-		n = int(nparam)
+	case n<0:
+		n = 0
+	}
+	offset := -1
+	if pathParam(r,"offset") != ""{
+		offset = intParam(r,"offset")
 	}
 	var html []string
 	html = append(html,"<html><head><title>Links</title></head><body>")
 	for i:=0;i<n;i++{
+		if i == offset{
+			html = append(html,fmt.Sprintf(` %d `,i))
+			continue
+		}
 		html = append(html,fmt.Sprintf(` <a href=/links/%d/%d> %d </a> `,n,i,i))
 	}
 	html = append(html,"</body></html>")
@@ -540,63 +672,104 @@ func LinkHandler(w http.ResponseWriter, r *http.Request){
 }
 
 //CacheHandler handles a GET request and sends a response in JSON format that contains url,args,header,IP of the coming request.
-//If "If-Modified-Since" or "If-None-Match" header is provided,it returns 304 status code.
+//It sets Last-Modified and ETag headers and returns 304 status code if "If-Modified-Since" or "If-None-Match" header matches them.
 func CacheHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
+	etag := fmt.Sprintf(`"%x"`,startTime.UnixNano())
+	w.Header().Set("Last-Modified",startTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("ETag",etag)
+	if checkPreconditions(w,r,etag,startTime){
+		return
 	}
-	jsonData := getAllJSONdata(r,"url","args","headers","origin")
-	//http date and uuid check
-	if r.Header.Get("If-Modified-Since" ) == "" && r.Header.Get("If-None-Match") == ""{
-		//uuid :=getAllJSONdata(r,"uuid")["uuid"]
-		w.Header().Set("Last-Modified","")
-		//w.Header().Set("ETag",strconv.Itoa([]byte(uuid.([]uint8))))
-		w.Write(makeJSONresponse(jsonData))
-	}else{
-		w.WriteHeader(304)
+	jsonData := getAllJSONdata(r,"url","args","headers","origin","protocol")
+	writeJSON(w,http.StatusOK,jsonData)
+}
+
+//EtagHandler handles a GET request and sends a response in JSON format that contains url,args,headers,IP of the coming request.
+//It assumes the resource has the given etag and answers If-None-Match with 304 and If-Match with 412 accordingly.
+func EtagHandler(w http.ResponseWriter, r *http.Request){
+	etag := pathParam(r,"etag")
+	if !strings.HasPrefix(etag,`"`) && !strings.HasPrefix(etag,`W/"`){
+		etag = `"`+etag+`"`
 	}
+	w.Header().Set("ETag",etag)
+	if checkPreconditions(w,r,etag,time.Time{}){
+		return
+	}
+	jsonData := getAllJSONdata(r,"url","args","headers","origin","protocol")
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //CacheControlHandler handles a GET request and sends a response in JSON format that contains url,args,headers,IP of the coming request.
 //It sets a Cache-Control header for n seconds.
 func CacheControlHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	//url check
-	strArr := strings.Split(r.URL.String(),"/")
-	nparam,err := strconv.ParseInt(strArr[2],10,64)
-	if err != nil {
-		nparam = 60
-	}
-	jsonData := getAllJSONdata(r,"url","args","headers","origin")
+	nparam := intParam(r,"n")
+	jsonData := getAllJSONdata(r,"url","args","headers","origin","protocol")
 	w.Header().Set("Cache-Control",fmt.Sprintf("public, max-age=%d",nparam))
-	w.Write(makeJSONresponse(jsonData))
+	writeJSON(w,http.StatusOK,jsonData)
 }
 
 //BytesHandler handles a GET request and sends a response that contains bytes which are generated n times randomly.
+//If seed parameter is given the same bytes are generated for the same seed.
 func BytesHandler(w http.ResponseWriter, r *http.Request){
-	if r.Method != "GET"{
-		http.Error(w,"Method Not Allowed",405)
-		return	
-	}
-	var n int
-	var byteArr []byte
-	urlStrArr := strings.Split(r.URL.String(),"/")
-	nparam, err := strconv.ParseInt(urlStrArr[2],10,64)
+	limits := *stateOf(r).limits
+	n := intParam(r,"n")
 	switch{
-	case err != nil:
-		n = 1024
-	case int(nparam)>100*1024:
-		n = 100*1024
-	case int(nparam)<=100*1024:
-		n = int(nparam)
+	case n>limits.Bytes:
+		n = limits.Bytes
+	case n<0:
+		n = 0
 	}
-	for i:=0;i<n;i++{
-		byteArr = append(byteArr,byte(rand.Int()))
+	random, err := randomSource(r)
+	if err != nil {
+		http.Error(w,"Invalid seed",http.StatusBadRequest)
+		return
 	}
+	byteArr := make([]byte,n)
+	random.Read(byteArr)
 	w.Header().Set("Content-Type","application/octet-stream")
 	w.Write(byteArr)
-}
\ No newline at end of file
+}
+
+//StreamBytesHandler handles a GET request and streams n random bytes in chunk_size pieces with chunked transfer encoding.
+//If seed parameter is given the same bytes are generated for the same seed, they are equal to the bytes of /bytes/:n.
+func StreamBytesHandler(w http.ResponseWriter, r *http.Request){
+	limits := *stateOf(r).limits
+	n := intParam(r,"n")
+	switch{
+	case n>limits.StreamBytes:
+		n = limits.StreamBytes
+	case n<0:
+		n = 0
+	}
+	chunkSize, err := queryInt(r,"chunk_size",10*1024)
+	if err != nil || chunkSize <= 0{
+		http.Error(w,"Invalid chunk_size",http.StatusBadRequest)
+		return
+	}
+	//A chunk is never larger than the whole stream, so chunk_size cannot make it allocate more than n bytes.
+	if chunkSize > n{
+		chunkSize = n
+	}
+	random, err := randomSource(r)
+	if err != nil {
+		http.Error(w,"Invalid seed",http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type","application/octet-stream")
+	flusher, _ := w.(http.Flusher)
+	chunk := make([]byte,chunkSize)
+	for n > 0{
+		if r.Context().Err() != nil{
+			return
+		}
+		if n < chunkSize{
+			chunk = chunk[:n]
+		}
+		random.Read(chunk)
+		w.Write(chunk)
+		if flusher != nil{
+			flusher.Flush()
+		}
+		n -= len(chunk)
+	}
+}
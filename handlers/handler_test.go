@@ -10,6 +10,22 @@ import(
 	"strings"
 	"flag"
 	"io/ioutil"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"time"
+	"context"
+	"mime"
+	"crypto/sha256"
+	"encoding/xml"
+	"image/jpeg"
+	"image/png"
+	"strconv"
+	"mime/multipart"
+	"net/textproto"
+	"github.com/andybalholm/brotli"
+	"github.com/tahasevim/responsiveweb/api"
 )
 
 //server is the test flag.
@@ -113,7 +129,7 @@ func TestIndexHandler(t *testing.T){
 
 	indexTemplate := templates.IndexTemplate
 	var templ bytes.Buffer
-	indexTemplate.Execute(&templ,nil)
+	indexTemplate.Execute(&templ,Routes())
 	expectedResult := templ.String()
 	result := resprec.Body.String()
 	if expectedResult != result {
@@ -253,6 +269,64 @@ func TestPutHandler(t *testing.T){
 	}
 
 }
+func TestPatchHandler(t *testing.T){
+	testReq, err := http.NewRequest("PATCH","/patch",strings.NewReader(`testK=testV`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(PatchHandler)
+	handler.ServeHTTP(resprec,testReq)
+
+	//Status Check	
+	if resprec.Code != http.StatusOK{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,resprec.Code)
+	}
+	var result api.EchoResponse
+	if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Data != "testK=testV" || len(result.Form) != 0 || result.Headers.Get("Content-Length") != "11" || string(result.JSON) != "null"{
+		t.Errorf("Unexpected result occurred:%+v",result)
+	}
+}
+
+func TestEchoHandlersMethods(t *testing.T){
+	cases := []struct{
+		path string
+		method string
+		code int
+		allow string
+	}{
+		{"/get","HEAD",http.StatusOK,""},
+		{"/get","OPTIONS",http.StatusOK,"GET, HEAD, OPTIONS"},
+		{"/get","POST",http.StatusMethodNotAllowed,"GET, HEAD, OPTIONS"},
+		{"/post","OPTIONS",http.StatusOK,"POST, OPTIONS"},
+		{"/post","GET",http.StatusMethodNotAllowed,"POST, OPTIONS"},
+		{"/put","DELETE",http.StatusMethodNotAllowed,"PUT, OPTIONS"},
+		{"/delete","PUT",http.StatusMethodNotAllowed,"DELETE, OPTIONS"},
+		{"/patch","PATCH",http.StatusOK,""},
+		{"/patch","HEAD",http.StatusMethodNotAllowed,"PATCH, OPTIONS"},
+		{"/anything","HEAD",http.StatusOK,""},
+		{"/anything/x/y","OPTIONS",http.StatusOK,"GET, HEAD, POST, PUT, PATCH, DELETE, TRACE, OPTIONS"},
+	}
+	router := NewRouter()
+	for _,c := range cases{
+		testReq, err := http.NewRequest(c.method,c.path,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		router.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v %v.\nExpected Result:%v\n Result:%v",c.method,c.path,c.code,resprec.Code)
+		}
+		if allow := resprec.Header().Get("Allow"); allow != c.allow{
+			t.Errorf("Unexpected Allow header for %v %v.\nExpected Result:%v\n Result:%v",c.method,c.path,c.allow,allow)
+		}
+	}
+}
+
 func TestAnythingHandler(t *testing.T){
 	flag.Parse()
 	body := strings.NewReader(`testK=testV`)
@@ -316,91 +390,90 @@ func TestEncodingUtfHandler(t *testing.T){
 	}
 }
 func  TestGzipHandler( t* testing.T){
-	flag.Parse()
-	req, err := http.NewRequest("GET",server+"/gzip",nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	resp,_ := http.DefaultClient.Do(req)
-	expectedResult ,_:= ioutil.ReadAll(resp.Body)
-
 	testReq, err := http.NewRequest("GET","/gzip",nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	resprec := httptest.NewRecorder()
 	handler := http.HandlerFunc(GzipHandler)
-	handler.ServeHTTP(resprec,testReq)//host url will be ignored while comparing
-	
-	result := deleteJSONval(resprec.Body.Bytes(),"url","origin")
-	expectedResult = deleteJSONval(expectedResult,"url","origin")
-	//Status Check	
-	if resprec.Code != resp.StatusCode{
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",resp.StatusCode,resprec.Code)
-	}
+	handler.ServeHTTP(resprec,testReq)
 
-	//Body check.Since body has a header key we don't have to check headers separately
-	if string(result) != string(expectedResult) {
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
+	if resprec.Code != http.StatusOK{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,resprec.Code)
 	}
-}
-
-/*func  TestDeflateHandler( t* testing.T){
-	req, err := http.NewRequest("GET",server+"/deflate",nil)
+	if enc := resprec.Header().Get("Content-Encoding"); enc != "gzip"{
+		t.Errorf("Unexpected Content-Encoding.\nExpected Result:%v\n Result:%v","gzip",enc)
+	}
+	gz, err := gzip.NewReader(resprec.Body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	resp,_ := http.DefaultClient.Do(req)
-	expectedResult ,_:= ioutil.ReadAll(resp.Body)
+	decoded, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(decoded,&result); err != nil {
+		t.Fatal(err)
+	}
+	if result["gzipped"] != true || result["method"] != "GET"{
+		t.Errorf("Unexpected result occurred:%v",result)
+	}
+}
 
+func  TestDeflateHandler( t* testing.T){
 	testReq, err := http.NewRequest("GET","/deflate",nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	resprec := httptest.NewRecorder()
-	handler := http.HandlerFunc(deflateHandler)
-	handler.ServeHTTP(resprec,testReq)//host url will be ignored while comparing
-	
-	result := deleteJSONval(resprec.Body.Bytes(),"url","origin")
-	expectedResult = deleteJSONval(expectedResult,"url","origin")
-	//Status Check	
-	if resprec.Code != resp.StatusCode{
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",resp.StatusCode,resprec.Code)
-	}
+	handler := http.HandlerFunc(DeflateHandler)
+	handler.ServeHTTP(resprec,testReq)
 
-	//Body check.Since body has a header key we don't have to check headers separately
-	if string(result) != string(expectedResult) {
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
+	if enc := resprec.Header().Get("Content-Encoding"); enc != "deflate"{
+		t.Errorf("Unexpected Content-Encoding.\nExpected Result:%v\n Result:%v","deflate",enc)
 	}
-}
-/*func  TestBrotliHandler( t* testing.T){
-	req, err := http.NewRequest("GET",server+"/brotli",nil)
+	zr, err := zlib.NewReader(resprec.Body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	resp,_ := http.DefaultClient.Do(req)
-	expectedResult ,_:= ioutil.ReadAll(resp.Body)
+	decoded, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(decoded,&result); err != nil {
+		t.Fatal(err)
+	}
+	if result["deflated"] != true{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",true,result["deflated"])
+	}
+}
 
+func  TestBrotliHandler( t* testing.T){
 	testReq, err := http.NewRequest("GET","/brotli",nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	resprec := httptest.NewRecorder()
-	handler := http.HandlerFunc(brotliHandler)
-	handler.ServeHTTP(resprec,testReq)//host url will be ignored while comparing
-	
-	result := deleteJSONval(resprec.Body.Bytes(),"url","origin")
-	expectedResult = deleteJSONval(expectedResult,"url","origin")
-	//Status Check	
-	if resprec.Code != resp.StatusCode{
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",resp.StatusCode,resprec.Code)
-	}
+	handler := http.HandlerFunc(BrotliHandler)
+	handler.ServeHTTP(resprec,testReq)
 
-	//Body check.Since body has a header key we don't have to check headers separately
-	if string(result) != string(expectedResult) {
-		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
+	if enc := resprec.Header().Get("Content-Encoding"); enc != "br"{
+		t.Errorf("Unexpected Content-Encoding.\nExpected Result:%v\n Result:%v","br",enc)
 	}
-}*/
+	decoded, err := ioutil.ReadAll(brotli.NewReader(resprec.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(decoded,&result); err != nil {
+		t.Fatal(err)
+	}
+	if result["brotli"] != true{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",true,result["brotli"])
+	}
+}
 func TestStatusHandler( t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/status/418",nil)
@@ -421,6 +494,57 @@ func TestStatusHandler( t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",resp.StatusCode,resprec.Code)
 	}
 }
+func TestStatusHandlerCodes(t *testing.T){
+	cases := []struct{
+		method, uri string
+		code int
+		header string
+	}{
+		{"GET","/status/200",200,""},
+		{"POST","/status/503",503,""},
+		{"DELETE","/status/204",204,""},
+		{"GET","/status/401",401,"WWW-Authenticate"},
+		{"GET","/status/407",407,"Proxy-Authenticate"},
+		{"GET","/status/418",418,"X-More-Info"},
+		{"GET","/status/302",302,"Location"},
+		{"GET","/status/500:0,201:1",201,""},
+		{"GET","/status/abc",http.StatusBadRequest,""},
+		{"GET","/status/200:x",http.StatusBadRequest,""},
+		{"GET","/status/200:0",http.StatusBadRequest,""},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest(c.method,c.uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		handler := http.HandlerFunc(StatusHandler)
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.uri,c.code,resprec.Code)
+		}
+		if c.header != "" && resprec.Header().Get(c.header) == ""{
+			t.Errorf("Missing %v header for %v",c.header,c.uri)
+		}
+	}
+}
+
+func TestStatusHandlerWeights(t *testing.T){
+	counts := map[int]int{}
+	for i:=0;i<1000;i++{
+		testReq, err := http.NewRequest("GET","/status/200:0.8,500:0.2",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		http.HandlerFunc(StatusHandler).ServeHTTP(resprec,testReq)
+		counts[resprec.Code]++
+	}
+	if len(counts) != 2 || counts[200] < 700 || counts[500] < 100{
+		t.Errorf("Unexpected distribution of status codes:%v",counts)
+	}
+}
+
 func TestResponseHeadersHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/response-headers",nil)
@@ -456,20 +580,39 @@ func TestResponseHeadersHandler(t *testing.T){
 	
 func TestRedirectMultiHandler(t *testing.T){
 	flag.Parse()
-	req, err := http.NewRequest("GET",server+"/redirect/10",nil)
+	req, err := http.NewRequest("GET",server+"/redirect/5",nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	resp,_ := http.DefaultClient.Do(req)
 	expectedResult ,_:= ioutil.ReadAll(resp.Body)
 
-	testReq, err := http.NewRequest("GET","/redirect/10",nil)
-	if err != nil {
-		t.Fatal(err)
+	//the client follows the whole chain (at most 10 redirects), so the handlers are chained here too
+	testReq, err := http.NewRequest("GET","/redirect/5",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resprec *httptest.ResponseRecorder
+	for hops := 0; ; hops++{
+		resprec = httptest.NewRecorder()
+		if strings.HasPrefix(testReq.URL.Path,"/redirect/"){
+			http.HandlerFunc(RedirectMultiHandler).ServeHTTP(resprec,testReq)
+		}else{
+			testReq.Header.Set("Referer",server+"/redirect/1")
+			http.HandlerFunc(GetHandler).ServeHTTP(resprec,testReq)
+			if hops != 5{
+				t.Errorf("Unexpected number of redirects.\nExpected Result:%v\n Result:%v",5,hops)
+			}
+			break
+		}
+		if resprec.Code != http.StatusFound{
+			t.Fatalf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusFound,resprec.Code)
+		}
+		testReq, err = http.NewRequest("GET",resprec.Header().Get("Location"),nil)
+		if err != nil {
+			t.Fatal(err)
+		}
 	}
-	resprec := httptest.NewRecorder()
-	handler := http.HandlerFunc(RedirectMultiHandler)
-	handler.ServeHTTP(resprec,testReq)//host url will be ignored while comparing
 	
 	result := deleteJSONval(resprec.Body.Bytes(),"url","origin")
 	expectedResult = deleteJSONval(expectedResult,"url","origin")
@@ -485,6 +628,38 @@ func TestRedirectMultiHandler(t *testing.T){
 	}
 }
 
+func TestRelativeAbsoluteRedirectHandler(t *testing.T){
+	cases := []struct{
+		uri string
+		handler http.HandlerFunc
+		location string
+	}{
+		{"/relative-redirect/3",RelativeRedirectHandler,"/relative-redirect/2"},
+		{"/relative-redirect/1",RelativeRedirectHandler,"/get"},
+		{"/absolute-redirect/3",AbsoluteRedirectHandler,"https://example.com/absolute-redirect/2"},
+		{"/absolute-redirect/1",AbsoluteRedirectHandler,"https://example.com/get"},
+		{"/redirect/3?absolute=true",RedirectMultiHandler,"https://example.com/absolute-redirect/2"},
+		{"/redirect/3",RedirectMultiHandler,"/redirect/2"},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest("GET",c.uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testReq.Host = "example.com"
+		testReq.RemoteAddr = "127.0.0.1:1234"
+		testReq.Header.Set("X-Forwarded-Proto","https")
+		resprec := httptest.NewRecorder()
+		c.handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != http.StatusFound{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.uri,http.StatusFound,resprec.Code)
+		}
+		if location := resprec.Header().Get("Location"); location != c.location{
+			t.Errorf("Unexpected Location for %v.\nExpected Result:%v\n Result:%v",c.uri,c.location,location)
+		}
+	}
+}
+
 /*func TestRedirectToHandler(t *testing.T){
 	req, err := http.NewRequest("GET",server+"/redirect-to",nil)
 	if err != nil {
@@ -672,6 +847,112 @@ func TestHiddenBasicAuthHandler(t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
 }
+//digestAuthorization answers the challenge of a DigestAuthHandler response.
+func digestAuthorization(t *testing.T, challenge, uri, user, passwd, nc string) string{
+	params := parseDigestAuth(challenge)
+	if params == nil {
+		t.Fatalf("Invalid challenge:%v",challenge)
+	}
+	h := digestHash(params["algorithm"])
+	ha1 := digestHex(h,user,params["realm"],passwd)
+	ha2 := digestHex(h,"GET",uri)
+	if params["qop"] == "auth-int"{
+		ha2 = digestHex(h,"GET",uri,digestHex(h,""))
+	}
+	cnonce := "0a4f113b"
+	response := digestHex(h,ha1,params["nonce"],nc,cnonce,params["qop"],ha2)
+	return fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, qop=%s, nc=%s, cnonce="%s", response="%s", opaque="%s"`,
+		user,params["realm"],params["nonce"],uri,params["algorithm"],params["qop"],nc,cnonce,response,params["opaque"])
+}
+
+func TestDigestAuthHandler(t *testing.T){
+	for _, uri := range []string{"/digest-auth/auth/testID/testPW","/digest-auth/auth-int/testID/testPW/SHA-256","/digest-auth/auth/testID/testPW/SHA-512-256/never"}{
+		testReq, err := http.NewRequest("GET",uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		handler := http.HandlerFunc(DigestAuthHandler)
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != http.StatusUnauthorized{
+			t.Fatalf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusUnauthorized,resprec.Code)
+		}
+
+		authReq, err := http.NewRequest("GET",uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authReq.Header.Set("Authorization",digestAuthorization(t,resprec.Header().Get("WWW-Authenticate"),uri,"testID","testPW","00000001"))
+		authRec := httptest.NewRecorder()
+		handler.ServeHTTP(authRec,authReq)
+		if authRec.Code != http.StatusOK{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",uri,http.StatusOK,authRec.Code)
+		}
+
+		authReq.Header.Set("Authorization",digestAuthorization(t,resprec.Header().Get("WWW-Authenticate"),uri,"testID","wrongPW","00000002"))
+		wrongRec := httptest.NewRecorder()
+		handler.ServeHTTP(wrongRec,authReq)
+		if wrongRec.Code != http.StatusUnauthorized{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",uri,http.StatusUnauthorized,wrongRec.Code)
+		}
+	}
+}
+
+func TestDigestAuthStaleAfter(t *testing.T){
+	uri := "/digest-auth/auth/testID/testPW/MD5/1"
+	testReq, err := http.NewRequest("GET",uri,nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(DigestAuthHandler)
+	handler.ServeHTTP(resprec,testReq)
+	challenge := resprec.Header().Get("WWW-Authenticate")
+
+	for i, expected := range []int{http.StatusOK,http.StatusUnauthorized}{
+		authReq, err := http.NewRequest("GET",uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authReq.Header.Set("Authorization",digestAuthorization(t,challenge,uri,"testID","testPW",fmt.Sprintf("%08x",i+1)))
+		authRec := httptest.NewRecorder()
+		handler.ServeHTTP(authRec,authReq)
+		if authRec.Code != expected{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",expected,authRec.Code)
+		}
+		if expected == http.StatusUnauthorized && !strings.Contains(authRec.Header().Get("WWW-Authenticate"),"stale=TRUE"){
+			t.Errorf("Expected a stale challenge, got:%v",authRec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestDigestNonceStore(t *testing.T){
+	defer func(max int){ maxDigestNonces = max }(maxDigestNonces)
+	maxDigestNonces = 3
+	nonces := newNonceStore()
+	var issued []string
+	for i := 0; i < 5; i++{
+		nonce, _ := newDigestNonce(nonces)
+		issued = append(issued,nonce)
+	}
+	if len(nonces.m) != 3{
+		t.Errorf("Unexpected number of nonces:%v",len(nonces.m))
+	}
+	for i, nonce := range issued{
+		if _, ok := nonces.m[nonce]; ok != (i >= 2){
+			t.Errorf("Unexpected presence of nonce %v:%v",i,ok)
+		}
+	}
+
+	//An expired nonce is dropped before the store is full.
+	nonces.m[issued[2]].created = time.Now().Add(-digestNonceLifetime-time.Second)
+	delete(nonces.m,issued[3])
+	newDigestNonce(nonces)
+	if _, ok := nonces.m[issued[2]]; ok || len(nonces.m) != 2 || len(nonces.order) != 2{
+		t.Errorf("Unexpected nonces after expiry:%v %v",nonces.m,nonces.order)
+	}
+}
+
 func TestStreamHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/stream/10",nil)
@@ -732,6 +1013,179 @@ func TestDelayHandler(t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
 }
+func TestDripHandler(t *testing.T){
+	testReq, err := http.NewRequest("GET","/drip?numbytes=5&duration=0.1&code=201",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(DripHandler)
+	start := time.Now()
+	handler.ServeHTTP(resprec,testReq)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond{
+		t.Errorf("Bytes were not dripped, elapsed:%v",elapsed)
+	}
+	if resprec.Code != 201{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",201,resprec.Code)
+	}
+	if result := resprec.Body.String(); result != "*****"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","*****",result)
+	}
+}
+
+func TestDripHandlerDurationLimit(t *testing.T){
+	defer func(limits Limits){ EndpointLimits = limits }(EndpointLimits)
+	EndpointLimits.Delay = 50*time.Millisecond
+	testReq, err := http.NewRequest("GET","/drip?numbytes=2&duration=100000",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(DripHandler)
+	start := time.Now()
+	handler.ServeHTTP(resprec,testReq)
+	if elapsed := time.Since(start); elapsed > 2*time.Second{
+		t.Errorf("Duration was not capped, elapsed:%v",elapsed)
+	}
+	if result := resprec.Body.String(); result != "**"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","**",result)
+	}
+}
+
+func TestDripHandlerCancel(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	testReq, err := http.NewRequest("GET","/drip?numbytes=100&duration=10",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReq = testReq.WithContext(ctx)
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(DripHandler)
+	time.AfterFunc(50*time.Millisecond,cancel)
+	start := time.Now()
+	handler.ServeHTTP(resprec,testReq)
+	if elapsed := time.Since(start); elapsed > 2*time.Second{
+		t.Errorf("Drip was not cancelled, elapsed:%v",elapsed)
+	}
+	if n := resprec.Body.Len(); n >= 100{
+		t.Errorf("Drip was not cancelled, %v bytes sent",n)
+	}
+}
+
+func TestRangeHandler(t *testing.T){
+	cases := []struct{
+		rangeHeader, ifRange string
+		code int
+		body, contentRange string
+	}{
+		{"","",http.StatusOK,"abcdefghijklmnopqrstuvwxyzabcd",""},
+		{"bytes=2-5","",http.StatusPartialContent,"cdef","bytes 2-5/30"},
+		{"bytes=-3","",http.StatusPartialContent,"bcd","bytes 27-29/30"},
+		{"bytes=25-","",http.StatusPartialContent,"zabcd","bytes 25-29/30"},
+		{"bytes=28-100","",http.StatusPartialContent,"cd","bytes 28-29/30"},
+		{"bytes=30-40","",http.StatusRequestedRangeNotSatisfiable,"","bytes */30"},
+		{"bytes=2-5",`"range30"`,http.StatusPartialContent,"cdef","bytes 2-5/30"},
+		{"bytes=2-5",`"other"`,http.StatusOK,"abcdefghijklmnopqrstuvwxyzabcd",""},
+		{"bytes=5-2","",http.StatusOK,"abcdefghijklmnopqrstuvwxyzabcd",""},
+		{"bytes=0-,0-","",http.StatusOK,"abcdefghijklmnopqrstuvwxyzabcd",""},
+		{"bytes=0-19,10-29","",http.StatusOK,"abcdefghijklmnopqrstuvwxyzabcd",""},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest("GET","/range/30",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.rangeHeader != ""{
+			testReq.Header.Set("Range",c.rangeHeader)
+		}
+		if c.ifRange != ""{
+			testReq.Header.Set("If-Range",c.ifRange)
+		}
+		resprec := httptest.NewRecorder()
+		handler := http.HandlerFunc(RangeHandler)
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.rangeHeader,c.code,resprec.Code)
+		}
+		if c.code != http.StatusRequestedRangeNotSatisfiable && resprec.Body.String() != c.body{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.rangeHeader,c.body,resprec.Body.String())
+		}
+		if cr := resprec.Header().Get("Content-Range"); cr != c.contentRange{
+			t.Errorf("Unexpected Content-Range for %v.\nExpected Result:%v\n Result:%v",c.rangeHeader,c.contentRange,cr)
+		}
+		if resprec.Header().Get("Accept-Ranges") != "bytes" || resprec.Header().Get("ETag") != `"range30"`{
+			t.Errorf("Missing Accept-Ranges or ETag header:%v",resprec.Header())
+		}
+	}
+}
+
+func TestRangeHandlerMultipart(t *testing.T){
+	testReq, err := http.NewRequest("GET","/range/30",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReq.Header.Set("Range","bytes=0-1,-2")
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(RangeHandler)
+	handler.ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusPartialContent{
+		t.Fatalf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusPartialContent,resprec.Code)
+	}
+	mediaType, params, err := mime.ParseMediaType(resprec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges"{
+		t.Fatalf("Unexpected Content-Type:%v",resprec.Header().Get("Content-Type"))
+	}
+	mr := multipart.NewReader(resprec.Body,params["boundary"])
+	expected := []struct{ body, contentRange string }{{"ab","bytes 0-1/30"},{"cd","bytes 28-29/30"}}
+	for _,e := range expected{
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(part)
+		if string(body) != e.body || part.Header.Get("Content-Range") != e.contentRange{
+			t.Errorf("Unexpected part.\nExpected Result:%v %v\n Result:%v %v",e.body,e.contentRange,string(body),part.Header.Get("Content-Range"))
+		}
+	}
+}
+
+func TestParseByteRangesLimits(t *testing.T){
+	many := strings.Repeat("0-0,",maxByteRanges)
+	cases := []struct{
+		header string
+		ranges int
+	}{
+		{"bytes="+many[:len(many)-1],maxByteRanges},
+		{"bytes="+many+"1-1",0},
+		{"bytes="+strings.Repeat("0-,",1000)+"0-",0},
+	}
+	for _,c := range cases{
+		ranges, err := parseByteRanges(c.header,1000)
+		if err != nil || len(ranges) != c.ranges{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v %v",c.ranges,len(ranges),err)
+		}
+	}
+}
+
+func TestRangeHandlerDurationLimit(t *testing.T){
+	defer func(limits Limits){ EndpointLimits = limits }(EndpointLimits)
+	EndpointLimits.Delay = 50*time.Millisecond
+	testReq, err := http.NewRequest("GET","/range/30?duration=100000&chunk_size=10",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(RangeHandler)
+	start := time.Now()
+	handler.ServeHTTP(resprec,testReq)
+	if elapsed := time.Since(start); elapsed > 2*time.Second{
+		t.Errorf("Duration was not capped, elapsed:%v",elapsed)
+	}
+	if resprec.Code != http.StatusOK || resprec.Body.Len() != 30{
+		t.Errorf("Unexpected result occurred:%v %v bytes",resprec.Code,resprec.Body.Len())
+	}
+}
+
 func TestHtmlHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/html",nil)
@@ -853,6 +1307,74 @@ func TestCacheHandler(t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
 }
+func TestCacheHandlerConditional(t *testing.T){
+	testReq, err := http.NewRequest("GET","/cache",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(CacheHandler)
+	handler.ServeHTTP(resprec,testReq)
+	etag, lastModified := resprec.Header().Get("ETag"), resprec.Header().Get("Last-Modified")
+	if resprec.Code != http.StatusOK || etag == "" || lastModified == ""{
+		t.Fatalf("Unexpected result occurred.\nCode:%v ETag:%v Last-Modified:%v",resprec.Code,etag,lastModified)
+	}
+	cases := []struct{
+		header, value string
+		code int
+	}{
+		{"If-None-Match",etag,http.StatusNotModified},
+		{"If-None-Match",`"stale"`,http.StatusOK},
+		{"If-Modified-Since",lastModified,http.StatusNotModified},
+		{"If-Modified-Since","Mon, 02 Jan 2006 15:04:05 GMT",http.StatusOK},
+	}
+	for _,c := range cases{
+		testReq.Header = http.Header{}
+		testReq.Header.Set(c.header,c.value)
+		resprec := httptest.NewRecorder()
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v: %v.\nExpected Result:%v\n Result:%v",c.header,c.value,c.code,resprec.Code)
+		}
+	}
+}
+
+func TestEtagHandler(t *testing.T){
+	cases := []struct{
+		header, value string
+		code int
+	}{
+		{"","",http.StatusOK},
+		{"If-None-Match",`"abc"`,http.StatusNotModified},
+		{"If-None-Match",`W/"abc"`,http.StatusNotModified},
+		{"If-None-Match",`"x", "abc"`,http.StatusNotModified},
+		{"If-None-Match","*",http.StatusNotModified},
+		{"If-None-Match",`"x"`,http.StatusOK},
+		{"If-Match",`"abc"`,http.StatusOK},
+		{"If-Match","*",http.StatusOK},
+		{"If-Match",`W/"abc"`,http.StatusPreconditionFailed},
+		{"If-Match",`"x"`,http.StatusPreconditionFailed},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest("GET","/etag/abc",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.header != ""{
+			testReq.Header.Set(c.header,c.value)
+		}
+		resprec := httptest.NewRecorder()
+		handler := http.HandlerFunc(EtagHandler)
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v: %v.\nExpected Result:%v\n Result:%v",c.header,c.value,c.code,resprec.Code)
+		}
+		if etag := resprec.Header().Get("ETag"); etag != `"abc"`{
+			t.Errorf("Unexpected ETag.\nExpected Result:%v\n Result:%v",`"abc"`,etag)
+		}
+	}
+}
+
 func TestCacheControlHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/cache/10",nil)
@@ -911,6 +1433,62 @@ func TestBytesHandler(t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
 }
+func TestBytesHandlerSeed(t *testing.T){
+	var results [][]byte
+	for _, uri := range []string{"/bytes/2048?seed=42","/bytes/2048?seed=42","/stream-bytes/2048?seed=42&chunk_size=100"}{
+		testReq, err := http.NewRequest("GET",uri,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		http.HandlerFunc(BytesHandler).ServeHTTP(resprec,testReq)
+		if strings.HasPrefix(uri,"/stream-bytes/"){
+			resprec = httptest.NewRecorder()
+			http.HandlerFunc(StreamBytesHandler).ServeHTTP(resprec,testReq)
+		}
+		if resprec.Code != http.StatusOK || resprec.Body.Len() != 2048{
+			t.Fatalf("Unexpected result occurred for %v.\nCode:%v Length:%v",uri,resprec.Code,resprec.Body.Len())
+		}
+		results = append(results,resprec.Body.Bytes())
+	}
+	if sha256.Sum256(results[0]) != sha256.Sum256(results[1]) || sha256.Sum256(results[0]) != sha256.Sum256(results[2]){
+		t.Errorf("Seeded bytes differ")
+	}
+}
+
+func TestStreamBytesHandler(t *testing.T){
+	testReq, err := http.NewRequest("GET","/stream-bytes/1000?chunk_size=300",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	handler := http.HandlerFunc(StreamBytesHandler)
+	handler.ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusOK || resprec.Body.Len() != 1000{
+		t.Errorf("Unexpected result occurred.\nCode:%v Length:%v",resprec.Code,resprec.Body.Len())
+	}
+	if !resprec.Flushed{
+		t.Errorf("Stream was not flushed")
+	}
+	if cl := resprec.Header().Get("Content-Length"); cl != ""{
+		t.Errorf("Stream must not have a Content-Length, got:%v",cl)
+	}
+}
+
+func TestStreamBytesHandlerLargeChunk(t *testing.T){
+	for _,chunkSize := range []string{"9223372036854775807","100000000000"}{
+		testReq, err := http.NewRequest("GET","/stream-bytes/10?chunk_size="+chunkSize,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		http.HandlerFunc(StreamBytesHandler).ServeHTTP(resprec,testReq)
+		if resprec.Code != http.StatusOK || resprec.Body.Len() != 10{
+			t.Errorf("Unexpected result occurred for chunk_size=%v.\nCode:%v Length:%v",chunkSize,resprec.Code,resprec.Body.Len())
+		}
+	}
+}
+
 func TestLinkHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/links/10",nil)
@@ -1087,6 +1665,77 @@ func TestImageSVGHandler(t *testing.T){
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
 }
+func TestImageHandlerAccept(t *testing.T){
+	cases := []struct{
+		accept string
+		code int
+		contentType string
+	}{
+		{"",http.StatusOK,"image/png"},
+		{"*/*",http.StatusOK,"image/png"},
+		{"image/*",http.StatusOK,"image/png"},
+		{"image/webp",http.StatusOK,"image/webp"},
+		{"image/svg+xml",http.StatusOK,"image/svg+xml"},
+		{"image/jpeg;q=0.5, image/png;q=0.4",http.StatusOK,"image/jpeg"},
+		{"image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8",http.StatusOK,"image/webp"},
+		{"image/*, image/png;q=0",http.StatusOK,"image/jpeg"},
+		{"text/html",http.StatusNotAcceptable,"application/json"},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest("GET","/image",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.accept != ""{
+			testReq.Header.Set("Accept",c.accept)
+		}
+		resprec := httptest.NewRecorder()
+		handler := http.HandlerFunc(ImageHandler)
+		handler.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.accept,c.code,resprec.Code)
+		}
+		if contentType := resprec.Header().Get("Content-Type"); contentType != c.contentType{
+			t.Errorf("Unexpected Content-Type for %v.\nExpected Result:%v\n Result:%v",c.accept,c.contentType,contentType)
+		}
+	}
+}
+
+func TestImageFormatHandlers(t *testing.T){
+	cases := []struct{
+		handler http.HandlerFunc
+		contentType string
+		check func([]byte) error
+	}{
+		{PngHandler,"image/png",func(b []byte) error{ _, err := png.Decode(bytes.NewReader(b)); return err }},
+		{JpegHandler,"image/jpeg",func(b []byte) error{ _, err := jpeg.Decode(bytes.NewReader(b)); return err }},
+		{WebpHandler,"image/webp",func(b []byte) error{
+			if string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP"{
+				return fmt.Errorf("invalid WEBP header")
+			}
+			return nil
+		}},
+		{SvgHandler,"image/svg+xml",func(b []byte) error{ return xml.Unmarshal(b,new(struct{})) }},
+	}
+	for _,c := range cases{
+		testReq, err := http.NewRequest("GET","/image",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		c.handler.ServeHTTP(resprec,testReq)
+		if contentType := resprec.Header().Get("Content-Type"); contentType != c.contentType{
+			t.Errorf("Unexpected Content-Type.\nExpected Result:%v\n Result:%v",c.contentType,contentType)
+		}
+		if cl := resprec.Header().Get("Content-Length"); cl != strconv.Itoa(resprec.Body.Len()){
+			t.Errorf("Unexpected Content-Length for %v.\nExpected Result:%v\n Result:%v",c.contentType,resprec.Body.Len(),cl)
+		}
+		if err := c.check(resprec.Body.Bytes()); err != nil {
+			t.Errorf("Invalid %v image:%v",c.contentType,err)
+		}
+	}
+}
+
 func TestFormsPostHandler(t *testing.T){
 	flag.Parse()
 	req, err := http.NewRequest("GET",server+"/forms/post",nil)
@@ -1144,4 +1793,255 @@ func TestXMLHandler(t *testing.T){
 	if string(result) != string(expectedResult) {
 		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",string(expectedResult), string(result))
 	}
-}
\ No newline at end of file
+}
+func TestExplorerHandler(t *testing.T){
+	tests := []struct{
+		path string
+		code int
+		contentType string
+	}{
+		{"/explorer",http.StatusOK,"text/html; charset=utf-8"},
+		{"/explorer/explorer.js",http.StatusOK,"javascript"},
+		{"/explorer/explorer.css",http.StatusOK,"text/css"},
+		{"/explorer/missing.js",http.StatusNotFound,"text/plain"},
+	}
+	for _,test := range tests{
+		testReq, err := http.NewRequest("GET",test.path,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		NewRouter().ServeHTTP(resprec,testReq)
+		if resprec.Code != test.code{
+			t.Errorf("Unexpected status of %v.\nExpected Result:%v\n Result:%v",test.path,test.code,resprec.Code)
+		}
+		if ct := resprec.Header().Get("Content-Type"); !strings.Contains(ct,test.contentType){
+			t.Errorf("Unexpected Content-Type of %v.\nExpected Result:%v\n Result:%v",test.path,test.contentType,ct)
+		}
+	}
+}
+
+func TestPostJSONHandler(t *testing.T){
+	defer func(size int64){ maxJSONSize = size }(maxJSONSize)
+	maxJSONSize = 64
+	tests := []struct{
+		contentType string
+		body string
+		json interface{}
+		err bool
+	}{
+		{"application/json",`{"a":{"b":[1,2.5,true,null,"c"]}}`,map[string]interface{}{"a":map[string]interface{}{"b":[]interface{}{1.0,2.5,true,nil,"c"}}},false},
+		{"application/json; charset=utf-8",`[1,2]`,[]interface{}{1.0,2.0},false},
+		{"application/merge-patch+json",`"text"`,"text",false},
+		{"application/json",`12345678901234567890`,12345678901234567890.0,false},
+		{"text/plain",`{"a":1}`,nil,false},
+		{"",`{"a":1}`,nil,false},
+		{"application/json",``,nil,false},
+		{"application/json",`{"a":`,nil,true},
+		{"application/json",`{"a":1} {"b":2}`,nil,true},
+		{"application/json",`"`+strings.Repeat("a",64)+`"`,nil,true},
+	}
+	for _,test := range tests{
+		testReq, err := http.NewRequest("POST","/post",strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.contentType != ""{
+			testReq.Header.Set("Content-Type",test.contentType)
+		}
+		resprec := httptest.NewRecorder()
+		PostHandler(resprec,testReq)
+		result := make(map[string]interface{})
+		if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(result["json"]) != fmt.Sprint(test.json){
+			t.Errorf("Unexpected json of %q.\nExpected Result:%v\n Result:%v",test.body,test.json,result["json"])
+		}
+		if _, ok := result["error"]; ok != test.err{
+			t.Errorf("Unexpected error of %q:%v",test.body,result["error"])
+		}
+	}
+	//Large integers must be echoed back exactly.
+	testReq, _ := http.NewRequest("POST","/post",strings.NewReader(`{"n":12345678901234567890}`))
+	testReq.Header.Set("Content-Type","application/json")
+	resprec := httptest.NewRecorder()
+	PostHandler(resprec,testReq)
+	if !strings.Contains(resprec.Body.String(),"12345678901234567890"){
+		t.Errorf("Large integer was not echoed back exactly:%v",resprec.Body.String())
+	}
+}
+
+func TestMultiValueHandler(t *testing.T){
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("custname","gopher")
+	mw.WriteField("topping","bacon")
+	mw.WriteField("topping","onion")
+	for _,content := range []string{"first","second"}{
+		fw, _ := mw.CreateFormFile("upload",content+".txt")
+		fw.Write([]byte(content))
+	}
+	fw, _ := mw.CreateFormFile("single","single.txt")
+	fw.Write([]byte("single"))
+	mw.Close()
+	testReq, err := http.NewRequest("POST","/anything?a=1&b=2&b=3",&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReq.Header.Set("Content-Type",mw.FormDataContentType())
+	testReq.Header.Add("X-Multi","x")
+	testReq.Header.Add("X-Multi","y")
+	resprec := httptest.NewRecorder()
+	AnythingHandler(resprec,testReq)
+	var result struct{
+		Args map[string]interface{} `json:"args"`
+		Form map[string]interface{} `json:"form"`
+		Files map[string]interface{} `json:"files"`
+		Headers map[string]interface{} `json:"headers"`
+	}
+	if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{
+		name string
+		got interface{}
+		expected string
+	}{
+		{"args a",result.Args["a"],"1"},
+		{"args b",result.Args["b"],"[2 3]"},
+		{"form custname",result.Form["custname"],"gopher"},
+		{"form topping",result.Form["topping"],"[bacon onion]"},
+		{"files upload",result.Files["upload"],"[first second]"},
+		{"files single",result.Files["single"],"single"},
+		{"headers X-Multi",result.Headers["X-Multi"],"[x y]"},
+	}
+	for _,test := range tests{
+		if fmt.Sprint(test.got) != test.expected{
+			t.Errorf("Unexpected %v.\nExpected Result:%v\n Result:%v",test.name,test.expected,test.got)
+		}
+	}
+}
+
+func TestFileUploadHandler(t *testing.T){
+	binary := []byte{0x89,'P','N','G',0,1,2,0xff}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("docs","a.txt")
+	fw.Write([]byte("text file"))
+	fw, _ = mw.CreateFormFile("docs","b.txt")
+	fw.Write([]byte("another"))
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition",`form-data; name="image"; filename="pig.png"`)
+	header.Set("Content-Type","image/png")
+	fw, _ = mw.CreatePart(header)
+	fw.Write(binary)
+	mw.Close()
+	testReq, err := http.NewRequest("POST","/post",bytes.NewReader(body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReq.Header.Set("Content-Type",mw.FormDataContentType())
+	resprec := httptest.NewRecorder()
+	PostHandler(resprec,testReq)
+	var result struct{
+		Files map[string]interface{} `json:"files"`
+		Uploads []struct{
+			Field string `json:"field"`
+			Filename string `json:"filename"`
+			ContentType string `json:"content_type"`
+			Size int `json:"size"`
+			SHA256 string `json:"sha256"`
+		} `json:"uploads"`
+	}
+	if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(result.Files["docs"]) != "[text file another]"{
+		t.Errorf("Unexpected docs files:%v",result.Files["docs"])
+	}
+	if expected := "data:image/png;base64,iVBORwABAv8="; result.Files["image"] != expected{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",expected,result.Files["image"])
+	}
+	if len(result.Uploads) != 3{
+		t.Fatalf("Unexpected number of uploads:%v",len(result.Uploads))
+	}
+	image := result.Uploads[2]
+	sum := sha256.Sum256(binary)
+	if image.Field != "image" || image.Filename != "pig.png" || image.ContentType != "image/png" || image.Size != len(binary) || image.SHA256 != fmt.Sprintf("%x",sum){
+		t.Errorf("Unexpected upload metadata:%+v",image)
+	}
+	if result.Uploads[0].Filename != "a.txt" || result.Uploads[1].Filename != "b.txt"{
+		t.Errorf("Unexpected upload order:%+v",result.Uploads)
+	}
+
+	defer func(size int64){ MaxUploadSize = size }(MaxUploadSize)
+	MaxUploadSize = int64(body.Len()-1)
+	testReq, _ = http.NewRequest("POST","/post",bytes.NewReader(body.Bytes()))
+	testReq.Header.Set("Content-Type",mw.FormDataContentType())
+	resprec = httptest.NewRecorder()
+	PostHandler(resprec,testReq)
+	if resprec.Code != http.StatusRequestEntityTooLarge{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusRequestEntityTooLarge,resprec.Code)
+	}
+	//The limit must hold when the length of the body is not known in advance.
+	testReq, _ = http.NewRequest("POST","/post",ioutil.NopCloser(bytes.NewReader(body.Bytes())))
+	testReq.ContentLength = -1
+	testReq.Header.Set("Content-Type",mw.FormDataContentType())
+	resprec = httptest.NewRecorder()
+	PostHandler(resprec,testReq)
+	if resprec.Code != http.StatusRequestEntityTooLarge{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusRequestEntityTooLarge,resprec.Code)
+	}
+}
+
+func TestBinaryDataHandler(t *testing.T){
+	tests := []struct{
+		contentType string
+		body string
+		data string
+	}{
+		{"text/plain","plain text","plain text"},
+		{"application/octet-stream","\x00\xff","data:application/octet-stream;base64,AP8="},
+		{"application/x-www-form-urlencoded","a=1",""},
+	}
+	for _,test := range tests{
+		testReq, err := http.NewRequest("POST","/post",strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testReq.Header.Set("Content-Type",test.contentType)
+		resprec := httptest.NewRecorder()
+		PostHandler(resprec,testReq)
+		result := make(map[string]interface{})
+		if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+			t.Fatal(err)
+		}
+		if result["data"] != test.data{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",test.data,result["data"])
+		}
+	}
+}
+
+func TestEchoResponseFields(t *testing.T){
+	keys := []string{"args","data","files","uploads","form","headers","json","origin","url","method","protocol","user-agent","uuid","gzipped","deflated","brotli","authenticated","user"}
+	testReq, _ := http.NewRequest("POST","/anything?k=v",strings.NewReader(`{"a":1}`))
+	testReq.Header.Set("Content-Type","application/json")
+	jsonData := getAllJSONdata(testReq,keys...)
+	for _,key := range keys{
+		if _,ok := jsonData[key]; !ok{
+			t.Errorf("%v is not a field of api.EchoResponse",key)
+		}
+	}
+	var echo api.EchoResponse
+	if err := json.Unmarshal(makeJSONresponse(jsonData),&echo); err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ A int `json:"a"` }
+	if err := echo.DecodeJSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if echo.Args.Get("k") != "v" || echo.Method != "POST" || body.A != 1 || !echo.Gzipped{
+		t.Errorf("Unexpected echo response:%+v",echo)
+	}
+}
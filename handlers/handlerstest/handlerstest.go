@@ -0,0 +1,27 @@
+//Package handlerstest starts the endpoints of the handlers package on httptest servers,
+//so that the tests of other programs can use them without a separately deployed server.
+package handlerstest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tahasevim/responsiveweb/handlers"
+)
+
+//NewServer starts a server of handlers.New(opts...) which is closed when the test finishes.
+//Every server has its own settings and state, so parallel tests do not affect each other.
+func NewServer(tb testing.TB, opts ...handlers.Option) *httptest.Server{
+	ts := httptest.NewServer(handlers.New(opts...))
+	tb.Cleanup(ts.Close)
+	return ts
+}
+
+//NewTLSServer is like NewServer but serves HTTPS and HTTP/2, the client of the server trusts its certificate.
+func NewTLSServer(tb testing.TB, opts ...handlers.Option) *httptest.Server{
+	ts := httptest.NewUnstartedServer(handlers.New(opts...))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	tb.Cleanup(ts.Close)
+	return ts
+}
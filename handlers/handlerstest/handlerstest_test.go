@@ -0,0 +1,52 @@
+package handlerstest
+
+import(
+	"testing"
+	"net/http"
+	"strings"
+	"github.com/tahasevim/responsiveweb/handlers"
+)
+
+func TestNewServer(t *testing.T){
+	tests := []struct{
+		name string
+		opts []handlers.Option
+		method string
+		path string
+		body string
+		code int
+	}{
+		{"all groups",nil,"GET","/ip","",http.StatusOK},
+		{"methods only",[]handlers.Option{handlers.WithGroups("methods")},"GET","/ip","",http.StatusNotFound},
+		{"small uploads",[]handlers.Option{handlers.WithMaxUploadSize(1)},"GET","/get","",http.StatusOK},
+		{"too large upload",[]handlers.Option{handlers.WithMaxUploadSize(1)},"POST","/post","too large",http.StatusRequestEntityTooLarge},
+	}
+	for _,test := range tests{
+		test := test
+		t.Run(test.name,func(t *testing.T){
+			t.Parallel()
+			ts := NewServer(t,test.opts...)
+			req, _ := http.NewRequest(test.method,ts.URL+test.path,strings.NewReader(test.body))
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != test.code{
+				t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",test.code,resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewTLSServer(t *testing.T){
+	ts := NewTLSServer(t)
+	resp, err := ts.Client().Get(ts.URL+"/http2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2{
+		t.Errorf("Unexpected response:%v %v",resp.StatusCode,resp.Proto)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+//HTTP2Conn is what the server saw on the HTTP/2 connection of a request.
+//The server puts it in the request context with WithHTTP2Conn, since net/http does not expose stream IDs or the SETTINGS of the peer.
+type HTTP2Conn interface {
+	//StreamID returns the ID of the stream which carried r, false if it is not known.
+	StreamID(r *http.Request) (uint32, bool)
+	//Settings returns the SETTINGS which the client and the server sent, by the names of the settings.
+	Settings() (client, server map[string]uint32)
+}
+
+//http2ConnKey is the context key of the HTTP2Conn of a request.
+type http2ConnKey struct{}
+
+//WithHTTP2Conn returns a copy of ctx which carries conn, the requests made with the context report it at /http2.
+func WithHTTP2Conn(ctx context.Context, conn HTTP2Conn) context.Context{
+	return context.WithValue(ctx, http2ConnKey{}, conn)
+}
+
+//HTTP2Handler handles a GET request and sends the protocol, stream ID and SETTINGS of the HTTP/2 connection in JSON format.
+//The stream ID and SETTINGS are null if the server did not record them,
+//and the SETTINGS may still be empty for the request which upgraded its connection to h2c, since it is served before they arrive.
+//It returns 400 status code if the request was not made over HTTP/2.
+func HTTP2Handler(w http.ResponseWriter, r *http.Request){
+	if r.ProtoMajor != 2 {
+		writeJSON(w, http.StatusBadRequest, jsonMap{"error": "the request was not made over HTTP/2", "protocol": r.Proto})
+		return
+	}
+	jsonData := jsonMap{"protocol": r.Proto, "tls": r.TLS != nil, "stream_id": nil, "settings": nil}
+	if conn, ok := r.Context().Value(http2ConnKey{}).(HTTP2Conn); ok {
+		if id, ok := conn.StreamID(r); ok {
+			jsonData["stream_id"] = id
+		}
+		client, server := conn.Settings()
+		jsonData["settings"] = jsonMap{"client": client, "server": server}
+	}
+	writeJSON(w, http.StatusOK, jsonData)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import(
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+)
+
+//fakeHTTP2Conn is an HTTP2Conn with fixed values.
+type fakeHTTP2Conn struct{}
+
+func (fakeHTTP2Conn) StreamID(r *http.Request) (uint32, bool){
+	return 3, true
+}
+
+func (fakeHTTP2Conn) Settings() (client, server map[string]uint32){
+	return map[string]uint32{"INITIAL_WINDOW_SIZE":65535}, map[string]uint32{"MAX_CONCURRENT_STREAMS":250}
+}
+
+func TestHTTP2Handler(t *testing.T){
+	req := httptest.NewRequest("GET","/http2",nil)
+	resprec := httptest.NewRecorder()
+	HTTP2Handler(resprec,req)
+	if resprec.Code != http.StatusBadRequest{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusBadRequest,resprec.Code)
+	}
+
+	req = httptest.NewRequest("GET","/http2",nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	req = req.WithContext(WithHTTP2Conn(req.Context(),fakeHTTP2Conn{}))
+	resprec = httptest.NewRecorder()
+	HTTP2Handler(resprec,req)
+	var result struct{
+		Protocol string
+		StreamID uint32 `json:"stream_id"`
+		Settings map[string]map[string]uint32
+	}
+	if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Protocol != "HTTP/2.0" || result.StreamID != 3 || result.Settings["server"]["MAX_CONCURRENT_STREAMS"] != 250{
+		t.Errorf("Unexpected result:%s",resprec.Body.Bytes())
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"time"
+)
+
+//The defaults of the settings, which the package variables start with too.
+const (
+	defaultMaxUploadSize  = 32 << 20
+	defaultTrustedProxies = "127.0.0.0/8,::1/128"
+)
+
+var defaultLimits = Limits{StreamLines: 100, Delay: 10 * time.Second, Bytes: 100 * 1024, StreamBytes: 10 * 1024 * 1024}
+
+//Option sets a setting of a handler made by New.
+type Option func(*instance)
+
+//WithMaxUploadSize sets the largest request body in bytes accepted by the endpoints, see MaxUploadSize.
+func WithMaxUploadSize(size int64) Option{
+	return func(in *instance){ *in.maxUploadSize = size }
+}
+
+//WithTrustedProxies sets the networks of the reverse proxies whose forwarding headers are believed, see TrustedProxies.
+func WithTrustedProxies(nets []*net.IPNet) Option{
+	return func(in *instance){ *in.trustedProxies = nets }
+}
+
+//WithLimits sets the limits which the endpoints apply, see EndpointLimits.
+func WithLimits(limits Limits) Option{
+	return func(in *instance){ *in.limits = limits }
+}
+
+//WithGroups sets the groups of the endpoints which are served, all groups are served if none is given and /ready in any case, see EnabledGroups.
+func WithGroups(groups ...string) Option{
+	return func(in *instance){ *in.groups = groups }
+}
+
+//WithClientCAs sets the CAs which the certificates of clients are verified against by /client-cert, see ClientCAs.
+func WithClientCAs(pool *x509.CertPool) Option{
+	return func(in *instance){ *in.clientCAs = pool }
+}
+
+//instance is the state which the handlers share: their settings, readiness and digest nonces.
+//A handler made by New has its own instance in the context of its requests, the others use the package variables,
+//which is why the settings are pointers.
+type instance struct {
+	maxUploadSize  *int64
+	trustedProxies *[]*net.IPNet
+	limits         *Limits
+	groups         *[]string
+	clientCAs      **x509.CertPool
+	notReady       *int32
+	nonces         *nonceStore
+}
+
+//instanceKey is the context key of the instance of a request.
+type instanceKey struct{}
+
+//packageInstance is the instance of the package variables, which may be changed at any time.
+var packageInstance = &instance{
+	maxUploadSize:  &MaxUploadSize,
+	trustedProxies: &TrustedProxies,
+	limits:         &EndpointLimits,
+	groups:         &EnabledGroups,
+	clientCAs:      &ClientCAs,
+	notReady:       &notReady,
+	nonces:         digestNonces,
+}
+
+//Handler serves the endpoints with its own router, settings and state, it is made by New.
+type Handler struct {
+	router *Router
+	in     *instance
+}
+
+//New returns a handler which serves the endpoints with its own router, settings and state,
+//so that several of them can serve at the same time, like in parallel tests.
+//The settings which are not given by opts have their default values, not those of the package variables.
+func New(opts ...Option) *Handler{
+	size, limits := int64(defaultMaxUploadSize), defaultLimits
+	trusted, _ := ParseTrustedProxies(defaultTrustedProxies)
+	var groups []string
+	var clientCAs *x509.CertPool
+	in := &instance{
+		maxUploadSize:  &size,
+		trustedProxies: &trusted,
+		limits:         &limits,
+		groups:         &groups,
+		clientCAs:      &clientCAs,
+		notReady:       new(int32),
+		nonces:         newNonceStore(),
+	}
+	for _, opt := range opts {
+		opt(in)
+	}
+	return &Handler{router: newRouter(enabledRoutes(*in.groups)), in: in}
+}
+
+//ServeHTTP serves r with the settings and state of h.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request){
+	h.router.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), instanceKey{}, h.in)))
+}
+
+//SetReady sets the readiness which is reported by /ready of h, the package SetReady does not change it.
+func (h *Handler) SetReady(ready bool){
+	setReady(h.in.notReady, ready)
+}
+
+//MaxUploadSize returns the largest request body in bytes which h accepts.
+func (h *Handler) MaxUploadSize() int64{
+	return *h.in.maxUploadSize
+}
+
+//stateOf returns the instance of the handler which serves r.
+func stateOf(r *http.Request) *instance{
+	if in, ok := r.Context().Value(instanceKey{}).(*instance); ok {
+		return in
+	}
+	return packageInstance
+}
+
+//enabledRoutes returns the routes which the instance serves.
+func (in *instance) enabledRoutes() []Route{
+	return enabledRoutes(*in.groups)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import(
+	"testing"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+func TestNewInstances(t *testing.T){
+	small := httptest.NewServer(New(WithLimits(Limits{StreamLines:2,Delay:time.Second,Bytes:10,StreamBytes:10}),WithMaxUploadSize(4)))
+	defer small.Close()
+	methods := httptest.NewServer(New(WithGroups("methods","dynamic")))
+	defer methods.Close()
+
+	countLines := func(url string) int{
+		resp, err := http.Get(url+"/stream/5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		lines := 0
+		for scanner := bufio.NewScanner(resp.Body); scanner.Scan(); {
+			lines++
+		}
+		return lines
+	}
+	if lines := countLines(small.URL); lines != 2{
+		t.Errorf("Limit of the instance was not applied.\nExpected Result:%v\n Result:%v",2,lines)
+	}
+	if lines := countLines(methods.URL); lines != 5{
+		t.Errorf("Limit of another instance was applied.\nExpected Result:%v\n Result:%v",5,lines)
+	}
+
+	tests := []struct{
+		url string
+		method string
+		path string
+		body string
+		code int
+	}{
+		{small.URL,"POST","/post","too long",http.StatusRequestEntityTooLarge},
+		{methods.URL,"POST","/post","too long",http.StatusOK},
+		{small.URL,"GET","/ip","",http.StatusOK},
+		{small.URL,"GET","/ip","too long",http.StatusRequestEntityTooLarge},
+		{methods.URL,"GET","/ip","",http.StatusNotFound},
+		{methods.URL,"GET","/ready","",http.StatusOK},
+	}
+	for _,test := range tests{
+		req, _ := http.NewRequest(test.method,test.url+test.path,strings.NewReader(test.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.code{
+			t.Errorf("%v %v: unexpected result occurred.\nExpected Result:%v\n Result:%v",test.method,test.path,test.code,resp.StatusCode)
+		}
+	}
+	//The package variables are not changed by the instances.
+	if MaxUploadSize != defaultMaxUploadSize || EndpointLimits != defaultLimits{
+		t.Errorf("Package settings were changed:%v %v",MaxUploadSize,EndpointLimits)
+	}
+}
+
+func TestHandlerSetReady(t *testing.T){
+	stopping, serving := New(), New()
+	stopping.SetReady(false)
+	defer SetReady(true)
+	SetReady(false)
+	for handler, code := range map[*Handler]int{stopping:http.StatusServiceUnavailable,serving:http.StatusOK}{
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w,httptest.NewRequest("GET","/ready",nil))
+		if w.Code != code{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",code,w.Code)
+		}
+	}
+}
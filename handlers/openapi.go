@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//openAPIVersion is the version of the OpenAPI specification which the generated document follows.
+const openAPIVersion = "3.0.3"
+
+//bodyMethods are the methods whose operations take a request body.
+var bodyMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+//openAPISchemas are the reusable schemas of the responses in JSON format.
+//Echo is api.EchoResponse, which getAllJSONdata produces, every endpoint fills only some of its fields.
+func openAPISchemas() jsonMap{
+	stringMap := jsonMap{"type": "object", "additionalProperties": jsonMap{"type": "string"}}
+	//valuesMap is the format of api.Values, a repeated key has an array of values.
+	valuesMap := jsonMap{"type": "object", "additionalProperties": jsonMap{"oneOf": []interface{}{
+		jsonMap{"type": "string"},
+		jsonMap{"type": "array", "items": jsonMap{"type": "string"}},
+	}}}
+	return jsonMap{
+		"Echo": jsonMap{
+			"type": "object",
+			"properties": jsonMap{
+				"args":          valuesMap,
+				"data":          jsonMap{"type": "string"},
+				"files":         valuesMap,
+				"uploads":       jsonMap{"type": "array", "items": schemaRef("Upload")},
+				"form":          valuesMap,
+				"headers":       valuesMap,
+				"json":          jsonMap{"nullable": true, "description": "The decoded body if its Content-Type is JSON."},
+				"error":         jsonMap{"type": "string", "description": "Why the body could not be decoded as JSON."},
+				"origin":        jsonMap{"type": "string"},
+				"url":           jsonMap{"type": "string"},
+				"method":        jsonMap{"type": "string"},
+				"protocol":      jsonMap{"type": "string", "description": "The protocol of the request, like HTTP/1.1 or HTTP/2.0."},
+				"user-agent":    jsonMap{"type": "string"},
+				"uuid":          jsonMap{"type": "string", "format": "uuid"},
+				"gzipped":       jsonMap{"type": "boolean"},
+				"deflated":      jsonMap{"type": "boolean"},
+				"brotli":        jsonMap{"type": "boolean"},
+				"authenticated": jsonMap{"type": "boolean"},
+				"user":          jsonMap{"type": "string"},
+			},
+		},
+		"Upload": jsonMap{
+			"type": "object",
+			"properties": jsonMap{
+				"field":        jsonMap{"type": "string"},
+				"filename":     jsonMap{"type": "string"},
+				"content_type": jsonMap{"type": "string"},
+				"size":         jsonMap{"type": "integer"},
+				"sha256":       jsonMap{"type": "string"},
+			},
+		},
+		"Cookies": jsonMap{
+			"type":       "object",
+			"properties": jsonMap{"cookies": stringMap},
+		},
+		"Route": jsonMap{
+			"type": "object",
+			"properties": jsonMap{
+				"methods":     jsonMap{"type": "array", "items": jsonMap{"type": "string"}},
+				"pattern":     jsonMap{"type": "string"},
+				"description": jsonMap{"type": "string"},
+				"example":     jsonMap{"type": "string"},
+				"produces":    jsonMap{"type": "string"},
+				"group":       jsonMap{"type": "string"},
+			},
+		},
+		"Headers": stringMap,
+		"ClientCert": jsonMap{
+			"type": "object",
+			"properties": jsonMap{
+				"verified": jsonMap{"type": "boolean"},
+				"error":    jsonMap{"type": "string", "description": "Why the chain could not be verified."},
+				"chain":    jsonMap{"type": "array", "items": schemaRef("Certificate")},
+			},
+		},
+		"Certificate": jsonMap{
+			"type": "object",
+			"properties": jsonMap{
+				"subject":      jsonMap{"type": "string"},
+				"issuer":       jsonMap{"type": "string"},
+				"sans":         jsonMap{"type": "object", "additionalProperties": jsonMap{"type": "array", "items": jsonMap{"type": "string"}}},
+				"serial":       jsonMap{"type": "string"},
+				"not_before":   jsonMap{"type": "string", "format": "date-time"},
+				"not_after":    jsonMap{"type": "string", "format": "date-time"},
+				"fingerprints": stringMap,
+			},
+		},
+	}
+}
+
+//schemaRef returns a reference to the named schema of openAPISchemas.
+func schemaRef(name string) jsonMap{
+	return jsonMap{"$ref": "#/components/schemas/" + name}
+}
+
+//responseSchema returns the schema of the response of the route.
+func responseSchema(route Route) jsonMap{
+	switch {
+	case route.Produces == "application/json":
+		switch route.Pattern {
+		case "/routes":
+			return jsonMap{"type": "array", "items": schemaRef("Route")}
+		case "/openapi.json":
+			return jsonMap{"type": "object"}
+		case "/cookies", "/cookies/set":
+			return schemaRef("Cookies")
+		case "/response-headers":
+			return schemaRef("Headers")
+		case "/ready":
+			return jsonMap{"type": "object", "properties": jsonMap{"ready": jsonMap{"type": "boolean"}}}
+		case "/client-cert":
+			return schemaRef("ClientCert")
+		case "/http2":
+			settings := jsonMap{"type": "object", "additionalProperties": jsonMap{"type": "integer"}}
+			return jsonMap{"type": "object", "properties": jsonMap{
+				"protocol":  jsonMap{"type": "string"},
+				"tls":       jsonMap{"type": "boolean"},
+				"stream_id": jsonMap{"type": "integer", "nullable": true},
+				"settings":  jsonMap{"type": "object", "nullable": true, "properties": jsonMap{"client": settings, "server": settings}},
+			}}
+		case "/tls-info":
+			return jsonMap{"type": "object", "properties": jsonMap{
+				"version":      jsonMap{"type": "string"},
+				"cipher_suite": jsonMap{"type": "string"},
+				"server_name":  jsonMap{"type": "string"},
+				"alpn":         jsonMap{"type": "string"},
+				"resumed":      jsonMap{"type": "boolean"},
+			}}
+		}
+		return schemaRef("Echo")
+	case strings.HasPrefix(route.Produces, "text/"), route.Produces == "application/xml", route.Produces == "application/yaml":
+		return jsonMap{"type": "string"}
+	}
+	return jsonMap{"type": "string", "format": "binary"}
+}
+
+//openAPIPath converts a route pattern to an OpenAPI path template and its path parameters.
+func openAPIPath(pattern string) (string, []interface{}){
+	parts := strings.Split(pattern, "/")
+	var params []interface{}
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			continue
+		}
+		name, typ := part[1:len(part)-1], "string"
+		if colon := strings.Index(name, ":"); colon >= 0 {
+			name, typ = name[:colon], name[colon+1:]
+		}
+		schema := jsonMap{"type": "string"}
+		description := ""
+		switch typ {
+		case "int":
+			schema = jsonMap{"type": "integer"}
+		case "path":
+			description = "The rest of the path, it may contain slashes."
+		}
+		param := jsonMap{"name": name, "in": "path", "required": true, "schema": schema}
+		if description != "" {
+			param["description"] = description
+		}
+		params = append(params, param)
+		parts[i] = "{" + name + "}"
+	}
+	return strings.Join(parts, "/"), params
+}
+
+//operationID returns a unique name of the operation, like "get_delay_n" for GET /delay/{n:int}.
+func operationID(method, path string) string{
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(c rune) bool{ return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') }) {
+		id += "_" + part
+	}
+	return id
+}
+
+//openAPIRequestBody is the request body of the operations which echo the body back.
+func openAPIRequestBody() jsonMap{
+	object := jsonMap{"type": "object", "additionalProperties": jsonMap{"type": "string"}}
+	return jsonMap{
+		"required": false,
+		"content": jsonMap{
+			"application/json":                  jsonMap{"schema": jsonMap{}},
+			"application/x-www-form-urlencoded": jsonMap{"schema": object},
+			"multipart/form-data":               jsonMap{"schema": jsonMap{"type": "object"}},
+			"text/plain":                        jsonMap{"schema": jsonMap{"type": "string"}},
+		},
+	}
+}
+
+//openAPISpec returns the OpenAPI 3 document of routes, served on serverURL.
+func openAPISpec(routes []Route, serverURL string) jsonMap{
+	paths := jsonMap{}
+	for _, route := range routes {
+		path, params := openAPIPath(route.Pattern)
+		item, ok := paths[path].(jsonMap)
+		if !ok {
+			item = jsonMap{}
+			paths[path] = item
+		}
+		if params != nil {
+			item["parameters"] = params
+		}
+		response := jsonMap{"description": route.Description}
+		if route.Produces != "" {
+			response["content"] = jsonMap{route.Produces: jsonMap{"schema": responseSchema(route)}}
+		}
+		for _, method := range route.Methods {
+			operation := jsonMap{
+				"operationId": operationID(method, path),
+				"summary":     route.Description,
+				"tags":        []string{route.Group},
+				"responses":   jsonMap{"default": response},
+			}
+			if route.Produces != "" {
+				operation["responses"] = jsonMap{"200": response}
+			}
+			if bodyMethods[method] && route.Produces == "application/json" {
+				operation["requestBody"] = openAPIRequestBody()
+				operation["responses"].(jsonMap)["413"] = jsonMap{"description": "The request body is larger than the upload limit."}
+			}
+			item[strings.ToLower(method)] = operation
+		}
+	}
+	return jsonMap{
+		"openapi": openAPIVersion,
+		"info": jsonMap{
+			"title":       "responsiveweb",
+			"description": "A HTTP test server for clients.",
+			"version":     "1.0.0",
+		},
+		"servers":    []interface{}{jsonMap{"url": serverURL}},
+		"paths":      paths,
+		"components": jsonMap{"schemas": openAPISchemas()},
+	}
+}
+
+//OpenAPIHandler handles a GET request and sends the OpenAPI 3 specification of all endpoints.
+//It is sent in YAML format for /openapi.yaml and in JSON format otherwise.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request){
+	spec := openAPISpec(stateOf(r).enabledRoutes(), absoluteURL(r, ""))
+	if strings.HasSuffix(r.URL.Path, ".yaml") {
+		body, err := yaml.Marshal(spec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(body)
+		return
+	}
+	writeJSON(w, http.StatusOK, spec)
+}
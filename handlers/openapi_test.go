@@ -0,0 +1,113 @@
+package handlers
+
+import(
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+	"mime"
+	"reflect"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+
+func getOpenAPI(t *testing.T, path string) *httptest.ResponseRecorder{
+	testReq, err := http.NewRequest("GET",path,nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	NewRouter().ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusOK{
+		t.Fatalf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,resprec.Code)
+	}
+	return resprec
+}
+
+func TestOpenAPIHandler(t *testing.T){
+	resprec := getOpenAPI(t,"/openapi.json")
+	if ct := resprec.Header().Get("Content-Type"); ct != "application/json"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","application/json",ct)
+	}
+	var spec struct{
+		OpenAPI string `json:"openapi"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(resprec.Body.Bytes(),&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != openAPIVersion{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",openAPIVersion,spec.OpenAPI)
+	}
+	for _,route := range Routes(){
+		path, _ := openAPIPath(route.Pattern)
+		if _, ok := spec.Paths[path]; !ok{
+			t.Errorf("Missing path:%v",path)
+		}
+	}
+
+	var params []struct{
+		Name string `json:"name"`
+		In string `json:"in"`
+		Schema struct{ Type string `json:"type"` } `json:"schema"`
+	}
+	if err := json.Unmarshal(spec.Paths["/delay/{n}"]["parameters"],&params); err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 || params[0].Name != "n" || params[0].In != "path" || params[0].Schema.Type != "integer"{
+		t.Errorf("Unexpected parameters of /delay/{n}:%+v",params)
+	}
+
+	var post struct{
+		RequestBody struct{ Content map[string]interface{} `json:"content"` } `json:"requestBody"`
+		Responses map[string]struct{
+			Content map[string]struct{ Schema map[string]string `json:"schema"` } `json:"content"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(spec.Paths["/post"]["post"],&post); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := post.RequestBody.Content["application/json"]; !ok{
+		t.Errorf("Missing JSON request body of /post")
+	}
+	if ref := post.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/Echo"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","#/components/schemas/Echo",ref)
+	}
+}
+
+func TestOpenAPIYAMLHandler(t *testing.T){
+	resprec := getOpenAPI(t,"/openapi.yaml")
+	if ct := resprec.Header().Get("Content-Type"); ct != "application/yaml"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","application/yaml",ct)
+	}
+	var fromYAML, fromJSON map[string]interface{}
+	if err := yaml.Unmarshal(resprec.Body.Bytes(),&fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(getOpenAPI(t,"/openapi.json").Body.Bytes(),&fromJSON)
+	paths, _ := fromYAML["paths"].(map[interface{}]interface{})
+	if len(paths) != len(fromJSON["paths"].(map[string]interface{})){
+		t.Errorf("Unexpected number of paths.\nExpected Result:%v\n Result:%v",len(fromJSON["paths"].(map[string]interface{})),len(paths))
+	}
+	if !reflect.DeepEqual(fromYAML["openapi"],fromJSON["openapi"]){
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",fromJSON["openapi"],fromYAML["openapi"])
+	}
+}
+
+func TestRoutesContentType(t *testing.T){
+	limits := defaultLimits
+	limits.Delay = 0
+	handler := New(WithLimits(limits))
+	for _,route := range Routes(){
+		resprec := httptest.NewRecorder()
+		handler.ServeHTTP(resprec,httptest.NewRequest(route.Methods[0],route.Example,nil))
+		if resprec.Code < 200 || resprec.Code > 299{
+			continue
+		}
+		//Every successful response has the media type which the spec declares, a wildcard like image/* matches its type.
+		mediaType, _, _ := mime.ParseMediaType(resprec.Header().Get("Content-Type"))
+		if wildcard := strings.TrimSuffix(route.Produces,"*"); mediaType != route.Produces && (wildcard == route.Produces || !strings.HasPrefix(mediaType,wildcard)){
+			t.Errorf("Unexpected Content-Type of %v.\nExpected Result:%v\n Result:%v",route.Example,route.Produces,resprec.Header().Get("Content-Type"))
+		}
+	}
+}
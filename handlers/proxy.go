@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+//TrustedProxies are the networks of the reverse proxies whose forwarding headers are believed.
+//Forwarded, X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Host and X-Real-IP are ignored unless the request comes from one of them.
+//
+//Deprecated: Use New with WithTrustedProxies.
+var TrustedProxies, _ = ParseTrustedProxies(defaultTrustedProxies)
+
+//ParseTrustedProxies parses a comma separated list of IP addresses and CIDR networks.
+func ParseTrustedProxies(list string) ([]*net.IPNet, error){
+	var nets []*net.IPNet
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+//isTrustedProxy reports whether addr is an IP address in the trusted proxies of the handler which serves r.
+func isTrustedProxy(r *http.Request, addr string) bool{
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range *stateOf(r).trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//remoteIP returns the IP address of the peer of the connection.
+func remoteIP(r *http.Request) string{
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+//forwardedElements parses the Forwarded headers of the request (RFC 7239) to one map of parameters per forwarding hop.
+//Parameter names are lower case and quoted values are unquoted.
+func forwardedElements(r *http.Request) []map[string]string{
+	var elements []map[string]string
+	for _, header := range r.Header["Forwarded"] {
+		for _, element := range splitQuoted(header, ',') {
+			params := make(map[string]string)
+			for _, pair := range splitQuoted(element, ';') {
+				eq := strings.IndexByte(pair, '=')
+				if eq < 0 {
+					continue
+				}
+				val := strings.TrimSpace(pair[eq+1:])
+				if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+					val = strings.Replace(val[1:len(val)-1], `\`, "", -1)
+				}
+				params[strings.ToLower(strings.TrimSpace(pair[:eq]))] = val
+			}
+			elements = append(elements, params)
+		}
+	}
+	return elements
+}
+
+//splitQuoted splits s at every sep which is not inside a quoted string.
+func splitQuoted(s string, sep byte) []string{
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+//nodeIP returns the IP address of a node of a forwarding header like "192.0.2.1:80", "[2001:db8::1]:80" or "2001:db8::1".
+//Obfuscated and unknown nodes are returned unchanged.
+func nodeIP(node string) string{
+	node = strings.TrimSpace(node)
+	if strings.HasPrefix(node, "[") {
+		if end := strings.IndexByte(node, ']'); end > 0 {
+			return node[1:end]
+		}
+	}
+	if strings.Count(node, ":") == 1 {
+		return node[:strings.IndexByte(node, ':')]
+	}
+	return node
+}
+
+//clientIP returns the IP address of the client which made the request.
+//If the peer is a trusted proxy, the forwarding chain from Forwarded or X-Forwarded-For is walked from the nearest hop
+//and the first address which is not a trusted proxy is the client. X-Real-IP is used when there is no chain.
+func clientIP(r *http.Request) string{
+	ip := remoteIP(r)
+	if !isTrustedProxy(r, ip) {
+		return ip
+	}
+	var chain []string
+	if elements := forwardedElements(r); len(elements) > 0 {
+		for _, element := range elements {
+			chain = append(chain, nodeIP(element["for"]))
+		}
+	} else {
+		for _, header := range r.Header["X-Forwarded-For"] {
+			for _, node := range strings.Split(header, ",") {
+				chain = append(chain, nodeIP(node))
+			}
+		}
+	}
+	if len(chain) == 0 {
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return nodeIP(realIP)
+		}
+		return ip
+	}
+	for i := len(chain) - 1; i > 0; i-- {
+		if !isTrustedProxy(r, chain[i]) {
+			return chain[i]
+		}
+	}
+	return chain[0]
+}
+
+//forwardedParam returns the given parameter of the hop nearest to the client from Forwarded, or else the first value of the header.
+//It returns "" unless the request comes from a trusted proxy.
+func forwardedParam(r *http.Request, param, header string) string{
+	if !isTrustedProxy(r, remoteIP(r)) {
+		return ""
+	}
+	if elements := forwardedElements(r); len(elements) > 0 {
+		return elements[0][param]
+	}
+	return strings.TrimSpace(strings.Split(r.Header.Get(header), ",")[0])
+}
+
+//requestScheme returns the scheme which was used by the client, honouring the forwarding headers of trusted proxies.
+func requestScheme(r *http.Request) string{
+	if proto := forwardedParam(r, "proto", "X-Forwarded-Proto"); proto != "" {
+		return strings.ToLower(proto)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+//requestHost returns the host which the client made the request to, honouring the forwarding headers of trusted proxies.
+func requestHost(r *http.Request) string{
+	if host := forwardedParam(r, "host", "X-Forwarded-Host"); host != "" {
+		return host
+	}
+	return r.Host
+}
+
+//absoluteURL returns path as an absolute URL on the host the client made the request to.
+func absoluteURL(r *http.Request, path string) string{
+	return requestScheme(r) + "://" + requestHost(r) + path
+}
+
+//requestURL returns the full URL which the client requested.
+func requestURL(r *http.Request) string{
+	return absoluteURL(r, r.URL.RequestURI())
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import(
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+)
+
+func TestClientIP(t *testing.T){
+	tests := []struct{
+		remoteAddr string
+		headers map[string]string
+		expected string
+	}{
+		{"203.0.113.5:4000",nil,"203.0.113.5"},
+		{"203.0.113.5:4000",map[string]string{"X-Forwarded-For":"198.51.100.1"},"203.0.113.5"},
+		{"127.0.0.1:4000",map[string]string{"X-Forwarded-For":"198.51.100.1"},"198.51.100.1"},
+		{"127.0.0.1:4000",map[string]string{"X-Forwarded-For":"6.6.6.6, 198.51.100.1, 127.0.0.2"},"198.51.100.1"},
+		{"127.0.0.1:4000",map[string]string{"X-Forwarded-For":"127.0.0.3, 127.0.0.2"},"127.0.0.3"},
+		{"127.0.0.1:4000",map[string]string{"X-Real-IP":"198.51.100.2"},"198.51.100.2"},
+		{"[::1]:4000",map[string]string{"Forwarded":`for="[2001:db8:cafe::17]:4711";proto=https, for=127.0.0.2`},"2001:db8:cafe::17"},
+		{"127.0.0.1:4000",map[string]string{"Forwarded":`For="198.51.100.3:80"`,"X-Forwarded-For":"198.51.100.4"},"198.51.100.3"},
+		{"127.0.0.1:4000",map[string]string{"Forwarded":`for=_hidden`},"_hidden"},
+		{"127.0.0.1:4000",nil,"127.0.0.1"},
+	}
+	for _,test := range tests{
+		testReq, err := http.NewRequest("GET","/ip",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testReq.RemoteAddr = test.remoteAddr
+		for k,v := range test.headers{
+			testReq.Header.Set(k,v)
+		}
+		if ip := clientIP(testReq); ip != test.expected{
+			t.Errorf("Unexpected result occurred for %v %v.\nExpected Result:%v\n Result:%v",test.remoteAddr,test.headers,test.expected,ip)
+		}
+	}
+}
+
+func TestRequestURL(t *testing.T){
+	tests := []struct{
+		remoteAddr string
+		headers map[string]string
+		expected string
+	}{
+		{"203.0.113.5:4000",nil,"http://example.com/get?a=1"},
+		{"203.0.113.5:4000",map[string]string{"X-Forwarded-Proto":"https"},"http://example.com/get?a=1"},
+		{"127.0.0.1:4000",map[string]string{"X-Forwarded-Proto":"https","X-Forwarded-Host":"public.example"},"https://public.example/get?a=1"},
+		{"127.0.0.1:4000",map[string]string{"Forwarded":`proto=https;host="public.example:8443", proto=http;host=example.com`},"https://public.example:8443/get?a=1"},
+	}
+	for _,test := range tests{
+		testReq, err := http.NewRequest("GET","http://example.com/get?a=1",nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testReq.RemoteAddr = test.remoteAddr
+		for k,v := range test.headers{
+			testReq.Header.Set(k,v)
+		}
+		resprec := httptest.NewRecorder()
+		GetHandler(resprec,testReq)
+		result := make(map[string]interface{})
+		if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+			t.Fatal(err)
+		}
+		if result["url"] != test.expected{
+			t.Errorf("Unexpected result occurred for %v %v.\nExpected Result:%v\n Result:%v",test.remoteAddr,test.headers,test.expected,result["url"])
+		}
+		if host := result["headers"].(map[string]interface{})["Host"]; host != "example.com"{
+			t.Errorf("Unexpected Host header.\nExpected Result:%v\n Result:%v","example.com",host)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T){
+	nets, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1,2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nets) != 3 || nets[1].String() != "192.0.2.1/32" || nets[2].String() != "2001:db8::1/128"{
+		t.Errorf("Unexpected networks:%v",nets)
+	}
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil{
+		t.Errorf("Invalid proxy was accepted")
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Route is an endpoint of the server: the methods it accepts, its path pattern, its handler and its documentation.
+//Produces is the media type of a successful response, it is empty if the response has no fixed body.
+//Group is the name of the group of related endpoints the route belongs to, groups can be enabled separately.
+//A pattern consists of literal segments and parameters like "/delay/{n:int}".
+//Parameter types are "string" (default, a single non-empty segment), "int" and "path" (the rest of the path, only as the last segment).
+type Route struct {
+	Methods     []string         `json:"methods"`
+	Pattern     string           `json:"pattern"`
+	Handler     http.HandlerFunc `json:"-"`
+	Description string           `json:"description"`
+	Example     string           `json:"example"`
+	Produces    string           `json:"produces"`
+	Group       string           `json:"group"`
+}
+
+//segment is a compiled segment of a route pattern.
+type segment struct {
+	literal string
+	param   string
+	typ     string
+}
+
+//compiledRoute is a route with its compiled pattern.
+type compiledRoute struct {
+	Route
+	segments []segment
+}
+
+//Router dispatches requests to routes by path and method.
+//It answers requests whose body is larger than MaxUploadSize with 413 before they reach a route.
+//It answers OPTIONS requests and requests with a wrong method with an Allow header and unknown paths with 404 in JSON format.
+type Router struct {
+	routes []compiledRoute
+}
+
+//paramsKey is the context key of the path parameters.
+type paramsKey struct{}
+
+//anyMethod is the list of methods of the endpoints which accept any method.
+var anyMethod = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "TRACE"}
+
+//EnabledGroups are the groups of the endpoints which are served, all groups are served if it is empty.
+//The routes of alwaysEnabled are served in any case.
+//
+//Deprecated: Use New with WithGroups.
+var EnabledGroups []string
+
+//Groups returns the names of all endpoint groups in the order of Routes.
+func Groups() []string{
+	var groups []string
+	for _, route := range Routes() {
+		groups = appendMethod(groups, route.Group)
+	}
+	return groups
+}
+
+//EnabledRoutes returns the routes of Routes which belong to EnabledGroups.
+func EnabledRoutes() []Route{
+	return enabledRoutes(EnabledGroups)
+}
+
+//alwaysEnabled are the patterns of the routes which are served whatever groups are enabled,
+//since load balancers need /ready to notice a graceful shutdown.
+var alwaysEnabled = map[string]bool{"/ready": true}
+
+//enabledRoutes returns the routes of Routes which belong to groups and those of alwaysEnabled, all of them if groups is empty.
+func enabledRoutes(groups []string) []Route{
+	if len(groups) == 0 {
+		return Routes()
+	}
+	var routes []Route
+	for _, route := range Routes() {
+		if alwaysEnabled[route.Pattern] {
+			routes = append(routes, route)
+			continue
+		}
+		for _, group := range groups {
+			if route.Group == group {
+				routes = append(routes, route)
+				break
+			}
+		}
+	}
+	return routes
+}
+
+//NewRouter returns a Router which serves every endpoint of EnabledRoutes with the settings of the package variables.
+//New serves them with settings of its own.
+func NewRouter() *Router{
+	return newRouter(EnabledRoutes())
+}
+
+//newRouter returns a Router which serves the given routes.
+func newRouter(routes []Route) *Router{
+	rt := &Router{}
+	for _, route := range routes {
+		rt.HandleRoute(route)
+	}
+	return rt
+}
+
+//Handle adds a route for the given pattern and methods.
+//HEAD is accepted wherever GET is accepted.
+func (rt *Router) Handle(pattern string, handler http.HandlerFunc, methods ...string){
+	rt.HandleRoute(Route{Methods: methods, Pattern: pattern, Handler: handler})
+}
+
+//HandleRoute adds the route to the router.
+func (rt *Router) HandleRoute(route Route){
+	var segments []segment
+	for _, part := range strings.Split(route.Pattern, "/")[1:] {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name, typ := part[1:len(part)-1], "string"
+			if colon := strings.Index(name, ":"); colon >= 0 {
+				name, typ = name[:colon], name[colon+1:]
+			}
+			segments = append(segments, segment{param: name, typ: typ})
+			continue
+		}
+		segments = append(segments, segment{literal: part})
+	}
+	rt.routes = append(rt.routes, compiledRoute{route, segments})
+}
+
+//Routes returns the route table of the router.
+func (rt *Router) Routes() []Route{
+	routes := make([]Route, len(rt.routes))
+	for i, route := range rt.routes {
+		routes[i] = route.Route
+	}
+	return routes
+}
+
+//match reports whether the path matches the route and returns the path parameters.
+func (route compiledRoute) match(path string) (map[string]string, bool){
+	parts := strings.Split(path, "/")[1:]
+	params := make(map[string]string)
+	for i, seg := range route.segments {
+		if seg.typ == "path" {
+			params[seg.param] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch {
+		case seg.param == "":
+			if parts[i] != seg.literal {
+				return nil, false
+			}
+		case parts[i] == "":
+			return nil, false
+		case seg.typ == "int":
+			if _, err := strconv.Atoi(parts[i]); err != nil {
+				return nil, false
+			}
+			params[seg.param] = parts[i]
+		default:
+			params[seg.param] = parts[i]
+		}
+	}
+	return params, len(parts) == len(route.segments)
+}
+
+//Accepts reports whether the route accepts the method.
+func (route Route) Accepts(method string) bool{
+	for _, m := range route.Methods {
+		if m == method || m == "GET" && method == "HEAD" {
+			return true
+		}
+	}
+	return false
+}
+
+//ServeHTTP dispatches the request to the first route which matches its path and method.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request){
+	var allowed []string
+	for _, route := range rt.routes {
+		params, ok := route.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		if route.Accepts(r.Method) {
+			r = r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+			//The body is read up to the upload limit for every endpoint, so none of them can read a larger one.
+			if !readBody(w, r) {
+				return
+			}
+			route.Handler(w, r)
+			return
+		}
+		for _, m := range route.Methods {
+			allowed = appendMethod(allowed, m)
+			if m == "GET" {
+				allowed = appendMethod(allowed, "HEAD")
+			}
+		}
+	}
+	if allowed == nil {
+		jsonData := jsonMap{}
+		jsonData["error"] = "Not Found"
+		jsonData["path"] = r.URL.Path
+		writeJSON(w, http.StatusNotFound, jsonData)
+		return
+	}
+	allowed = appendMethod(allowed, "OPTIONS")
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	if r.Method == "OPTIONS" {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+}
+
+//appendMethod appends method to methods unless it is already there, it is used for any list of unique names.
+func appendMethod(methods []string, method string) []string{
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
+
+//fallbackRouter resolves the path parameters of requests which did not come through a Router.
+var fallbackRouter struct {
+	sync.Once
+	*Router
+}
+
+//pathParam returns the named path parameter of the request.
+//Handlers may be called without a Router, then the parameters are found by matching the path against Routes.
+func pathParam(r *http.Request, name string) string{
+	if params, ok := r.Context().Value(paramsKey{}).(map[string]string); ok {
+		return params[name]
+	}
+	fallbackRouter.Do(func(){
+		fallbackRouter.Router = newRouter(Routes())
+	})
+	for _, route := range fallbackRouter.routes {
+		if params, ok := route.match(r.URL.Path); ok {
+			return params[name]
+		}
+	}
+	return ""
+}
+
+//intParam returns the named path parameter of the request as an integer.
+func intParam(r *http.Request, name string) int{
+	n, _ := strconv.Atoi(pathParam(r, name))
+	return n
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import(
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+	"net/url"
+	"bytes"
+	"mime/multipart"
+	"strings"
+)
+
+func TestRouterParams(t *testing.T){
+	router := &Router{}
+	var got map[string]string
+	router.Handle("/items/{id:int}/{name}",func(w http.ResponseWriter, r *http.Request){
+		got = map[string]string{"id":pathParam(r,"id"),"name":pathParam(r,"name")}
+	},"GET")
+	router.Handle("/files/{file:path}",func(w http.ResponseWriter, r *http.Request){
+		got = map[string]string{"file":pathParam(r,"file")}
+	},"GET")
+	cases := []struct{
+		path string
+		code int
+		params map[string]string
+	}{
+		{"/items/12/foo",http.StatusOK,map[string]string{"id":"12","name":"foo"}},
+		{"/items/abc/foo",http.StatusNotFound,nil},
+		{"/items/12",http.StatusNotFound,nil},
+		{"/items/12/foo/bar",http.StatusNotFound,nil},
+		{"/files/a/b/c.txt",http.StatusOK,map[string]string{"file":"a/b/c.txt"}},
+	}
+	for _,c := range cases{
+		got = nil
+		testReq, err := http.NewRequest("GET",c.path,nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resprec := httptest.NewRecorder()
+		router.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v.\nExpected Result:%v\n Result:%v",c.path,c.code,resprec.Code)
+		}
+		for k,v := range c.params{
+			if got[k] != v{
+				t.Errorf("Unexpected parameter %v for %v.\nExpected Result:%v\n Result:%v",k,c.path,v,got[k])
+			}
+		}
+	}
+}
+
+func TestRouterNotFound(t *testing.T){
+	testReq, err := http.NewRequest("GET","/delay/abc",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	NewRouter().ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusNotFound{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusNotFound,resprec.Code)
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(resprec.Body.Bytes(),&result); err != nil {
+		t.Fatal(err)
+	}
+	if result["path"] != "/delay/abc"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v","/delay/abc",result["path"])
+	}
+}
+
+func TestRouterRoutes(t *testing.T){
+	routes := NewRouter().Routes()
+	if len(routes) != len(Routes()){
+		t.Errorf("Unexpected number of routes.\nExpected Result:%v\n Result:%v",len(Routes()),len(routes))
+	}
+	for _,route := range routes{
+		if len(route.Methods) == 0 || route.Handler == nil{
+			t.Errorf("Incomplete route:%v",route.Pattern)
+		}
+	}
+}
+
+func TestRoutesHandler(t *testing.T){
+	testReq, err := http.NewRequest("GET","/routes",nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resprec := httptest.NewRecorder()
+	NewRouter().ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusOK{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,resprec.Code)
+	}
+	var routes []Route
+	if err := json.Unmarshal(resprec.Body.Bytes(),&routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != len(Routes()){
+		t.Errorf("Unexpected number of routes.\nExpected Result:%v\n Result:%v",len(Routes()),len(routes))
+	}
+	router := NewRouter()
+	for _,route := range routes{
+		if route.Description == "" || route.Example == ""{
+			t.Errorf("Undocumented route:%v",route.Pattern)
+			continue
+		}
+		//Every example must be served by the route it documents.
+		exampleURL, err := url.Parse(route.Example)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matched := false
+		for _,compiled := range router.routes{
+			if _, ok := compiled.match(exampleURL.Path); ok{
+				matched = compiled.Pattern == route.Pattern
+				break
+			}
+		}
+		if !matched{
+			t.Errorf("Example %v does not match route %v",route.Example,route.Pattern)
+		}
+	}
+}
+
+func TestRouterUploadLimit(t *testing.T){
+	defer func(size int64){ MaxUploadSize = size }(MaxUploadSize)
+	MaxUploadSize = 1000
+	var upload bytes.Buffer
+	mw := multipart.NewWriter(&upload)
+	part, _ := mw.CreateFormFile("file","big.txt")
+	part.Write(bytes.Repeat([]byte("a"),5000))
+	mw.Close()
+	cases := []struct{
+		method, path, contentType string
+		body []byte
+		code int
+	}{
+		{"GET","/delay/0",mw.FormDataContentType(),upload.Bytes(),http.StatusRequestEntityTooLarge},
+		{"GET","/get","text/plain",bytes.Repeat([]byte("a"),5000),http.StatusRequestEntityTooLarge},
+		{"POST","/post","text/plain",bytes.Repeat([]byte("a"),5000),http.StatusRequestEntityTooLarge},
+		{"GET","/get","text/plain",bytes.Repeat([]byte("a"),1000),http.StatusOK},
+	}
+	router := NewRouter()
+	for _,c := range cases{
+		testReq, err := http.NewRequest(c.method,c.path,bytes.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testReq.Header.Set("Content-Type",c.contentType)
+		resprec := httptest.NewRecorder()
+		router.ServeHTTP(resprec,testReq)
+		if resprec.Code != c.code{
+			t.Errorf("Unexpected result occurred for %v %v.\nExpected Result:%v\n Result:%v",c.method,c.path,c.code,resprec.Code)
+		}
+	}
+
+	//A body without a Content-Length is cut off at the limit too.
+	testReq, err := http.NewRequest("GET","/get",strings.NewReader(strings.Repeat("a",5000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReq.ContentLength = -1
+	resprec := httptest.NewRecorder()
+	router.ServeHTTP(resprec,testReq)
+	if resprec.Code != http.StatusRequestEntityTooLarge{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusRequestEntityTooLarge,resprec.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//ClientCAs are the CAs which the certificates of clients are verified against by /client-cert.
+//The system roots are used if it is nil.
+//
+//Deprecated: Use New with WithClientCAs.
+var ClientCAs *x509.CertPool
+
+//tlsVersions are the names of the TLS versions.
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+//tlsVersionName returns the name of a TLS version.
+func tlsVersionName(version uint16) string{
+	if name, ok := tlsVersions[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+//TLSInfoHandler handles a GET request and sends the negotiated TLS version, cipher suite, SNI server name and ALPN protocol of the connection in JSON format.
+//It returns 400 status code if the request was not made over TLS.
+func TLSInfoHandler(w http.ResponseWriter, r *http.Request){
+	if r.TLS == nil {
+		writeJSON(w, http.StatusBadRequest, jsonMap{"error": "the request was not made over TLS"})
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonMap{
+		"version":      tlsVersionName(r.TLS.Version),
+		"cipher_suite": tls.CipherSuiteName(r.TLS.CipherSuite),
+		"server_name":  r.TLS.ServerName,
+		"alpn":         r.TLS.NegotiatedProtocol,
+		"resumed":      r.TLS.DidResume,
+	})
+}
+
+//certificateInfo returns the subject, issuer, SANs, serial number, validity and fingerprints of a certificate.
+func certificateInfo(cert *x509.Certificate) jsonMap{
+	sha1Sum, sha256Sum := sha1.Sum(cert.Raw), sha256.Sum256(cert.Raw)
+	ips := make([]string, len(cert.IPAddresses))
+	for i, ip := range cert.IPAddresses {
+		ips[i] = ip.String()
+	}
+	uris := make([]string, len(cert.URIs))
+	for i, uri := range cert.URIs {
+		uris[i] = uri.String()
+	}
+	return jsonMap{
+		"subject": cert.Subject.String(),
+		"issuer":  cert.Issuer.String(),
+		"sans": jsonMap{
+			"dns":   append([]string{}, cert.DNSNames...),
+			"email": append([]string{}, cert.EmailAddresses...),
+			"ip":    ips,
+			"uri":   uris,
+		},
+		"serial":     fmt.Sprintf("%X", cert.SerialNumber),
+		"not_before": cert.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":  cert.NotAfter.UTC().Format(time.RFC3339),
+		"fingerprints": jsonMap{
+			"sha1":   hex.EncodeToString(sha1Sum[:]),
+			"sha256": hex.EncodeToString(sha256Sum[:]),
+		},
+	}
+}
+
+//verifyClientChain verifies the certificate chain which a client presented against roots.
+func verifyClientChain(roots *x509.CertPool, chain []*x509.Certificate) error{
+	if len(chain) == 0 {
+		return fmt.Errorf("no client certificate was presented")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+//ClientCertHandler handles a GET request and sends the certificate chain which the client presented in JSON format.
+//The chain is verified against ClientCAs by the handler, so that the TLS handshake succeeds and a failed verification is answered with 403 status code.
+//It returns 400 status code if the request was not made over TLS.
+func ClientCertHandler(w http.ResponseWriter, r *http.Request){
+	if r.TLS == nil {
+		writeJSON(w, http.StatusBadRequest, jsonMap{"error": "the request was not made over TLS"})
+		return
+	}
+	chain := make([]interface{}, len(r.TLS.PeerCertificates))
+	for i, cert := range r.TLS.PeerCertificates {
+		chain[i] = certificateInfo(cert)
+	}
+	jsonData := jsonMap{"verified": true, "chain": chain}
+	code := http.StatusOK
+	if err := verifyClientChain(*stateOf(r).clientCAs, r.TLS.PeerCertificates); err != nil {
+		jsonData["verified"] = false
+		jsonData["error"] = err.Error()
+		code = http.StatusForbidden
+	}
+	writeJSON(w, code, jsonData)
+}
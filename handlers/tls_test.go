@@ -0,0 +1,115 @@
+package handlers
+
+import(
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"time"
+)
+
+func TestTLSInfoHandler(t *testing.T){
+	ts := httptest.NewTLSServer(http.HandlerFunc(TLSInfoHandler))
+	defer ts.Close()
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var info map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info["version"] != tlsVersionName(resp.TLS.Version) || info["cipher_suite"] == "" || info["alpn"] != resp.TLS.NegotiatedProtocol{
+		t.Errorf("Unexpected TLS info:%v",info)
+	}
+
+	req := httptest.NewRequest("GET","/tls-info",nil)
+	resprec := httptest.NewRecorder()
+	TLSInfoHandler(resprec,req)
+	if resprec.Code != http.StatusBadRequest{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusBadRequest,resprec.Code)
+	}
+}
+
+//newTestCertificate returns a certificate for name signed by parent, or a self-signed CA if parent is nil.
+func newTestCertificate(t *testing.T, name string, parent *tls.Certificate) tls.Certificate{
+	key, err := ecdsa.GenerateKey(elliptic.P256(),rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:big.NewInt(time.Now().UnixNano()),
+		Subject:pkix.Name{CommonName:name},
+		DNSNames:[]string{name},
+		NotBefore:time.Now().Add(-time.Hour),
+		NotAfter:time.Now().Add(time.Hour),
+		ExtKeyUsage:[]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	issuer, signer := template, interface{}(key)
+	if parent == nil{
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage = x509.KeyUsageCertSign
+	}else{
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader,template,issuer,&key.PublicKey,signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate:[][]byte{der},PrivateKey:key,Leaf:leaf}
+}
+
+func TestClientCertHandler(t *testing.T){
+	ca, other := newTestCertificate(t,"ca",nil), newTestCertificate(t,"other ca",nil)
+	defer func(pool *x509.CertPool){ ClientCAs = pool }(ClientCAs)
+	ClientCAs = x509.NewCertPool()
+	ClientCAs.AddCert(ca.Leaf)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(ClientCertHandler))
+	ts.TLS = &tls.Config{ClientAuth:tls.RequestClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+	tests := []struct{
+		name string
+		certs []tls.Certificate
+		code int
+		subject string
+	}{
+		{"trusted client",[]tls.Certificate{newTestCertificate(t,"client",&ca)},http.StatusOK,"CN=client"},
+		{"untrusted client",[]tls.Certificate{newTestCertificate(t,"stranger",&other)},http.StatusForbidden,"CN=stranger"},
+		{"no certificate",nil,http.StatusForbidden,""},
+	}
+	for _,test := range tests{
+		transport := ts.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = test.certs
+		resp, err := (&http.Client{Transport:transport}).Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result struct{
+			Verified bool
+			Chain []struct{
+				Subject string
+				Issuer string
+				Fingerprints map[string]string
+			}
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != test.code || result.Verified != (test.code == http.StatusOK){
+			t.Errorf("%v: unexpected result occurred.\nExpected Result:%v\n Result:%v",test.name,test.code,resp.StatusCode)
+		}
+		if test.subject != "" && (len(result.Chain) != 1 || result.Chain[0].Subject != test.subject || len(result.Chain[0].Fingerprints["sha256"]) != 64){
+			t.Errorf("%v: unexpected chain:%+v",test.name,result.Chain)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"strconv"
-	"log"
 	"io/ioutil"
 	//"log"
 	"io"
@@ -11,7 +10,21 @@ import (
 	"net/http"
 	"os/exec"
 	"strings"
-
+	"time"
+	"fmt"
+	"errors"
+	"sort"
+	"unicode/utf8"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/base64"
+	"mime"
+	"math/rand"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"github.com/tahasevim/responsiveweb/api"
+	"github.com/tahasevim/responsiveweb/templates"
 )
 
 type jsonMap map[string]interface{}
@@ -24,17 +37,46 @@ func makeJSONresponse(v interface {}) []byte {
 	return jsonVal
 }
 
-func initHeadMap(r * http.Request,body []byte) jsonMap{
-	head := jsonMap{}
-	for k,v := range r.Header{
-		head[k] = v[0]
+//writeJSON sends v in JSON format with the status code and the application/json Content-Type which the routes declare.
+func writeJSON(w http.ResponseWriter, code int, v interface{}){
+	w.Header().Set("Content-Type","application/json")
+	w.WriteHeader(code)
+	w.Write(makeJSONresponse(v))
+}
+
+//writeEncoded compresses body with the given content-coding ("gzip", "deflate" or "br") and writes it with the matching headers.
+//"deflate" is the zlib format defined by RFC 1950, which is what HTTP clients expect for that coding.
+func writeEncoded(w http.ResponseWriter, encoding string, body []byte){
+	var buf bytes.Buffer
+	var cw io.WriteCloser
+	switch encoding{
+	case "gzip":
+		cw = gzip.NewWriter(&buf)
+	case "deflate":
+		cw = zlib.NewWriter(&buf)
+	case "br":
+		cw = brotli.NewWriter(&buf)
+	default:
+		w.Write(body)
+		return
 	}
-	head["Host"] = r.URL.String()
-	if r.Method == "POST" || r.Method == "DELETE" || r.Method == "PUT"{
-		head["Content-Length"] = strconv.Itoa(len(body))
+	cw.Write(body)
+	cw.Close()
+	w.Header().Set("Content-Type","application/json")
+	w.Header().Set("Content-Encoding",encoding)
+	w.Header().Set("Content-Length",strconv.Itoa(buf.Len()))
+	w.Header().Add("Vary","Accept-Encoding")
+	w.Write(buf.Bytes())
+}
+
+func initHeadMap(r * http.Request,body []byte) api.Values{
+	head := api.Values{}
+	for k,v := range r.Header{
+		head[k] = v
 	}
-	if r.ProtoMajor == 1{
-		head["Connection"] = "close"
+	head["Host"] = []string{r.Host}
+	if r.Method == "POST" || r.Method == "DELETE" || r.Method == "PUT" || r.Method == "PATCH"{
+		head["Content-Length"] = []string{strconv.Itoa(len(body))}
 	}
 	return head
 }
@@ -54,36 +96,130 @@ func deleteJSONval(val []byte, keys ...string) []byte{
 	result = append(result,byte('\n'))
 	return result
 }
-func initQueryMap(r * http.Request) jsonMap{
-	getData := jsonMap{}
-	for k,v := range r.URL.Query(){
-		getData[k] = v[0]
+func initQueryMap(r * http.Request) api.Values{
+	return api.Values(r.URL.Query())
+}
+
+//MaxUploadSize is the largest request body in bytes accepted by the endpoints, larger ones are answered with 413.
+//
+//Deprecated: Use New with WithMaxUploadSize.
+var MaxUploadSize int64 = defaultMaxUploadSize
+
+//uploadedFile is a file of a multipart/form-data request.
+type uploadedFile struct {
+	api.Upload
+	data []byte
+}
+
+//bufferedBody is a request body which readBody has already read into memory.
+type bufferedBody struct{
+	*bytes.Reader
+}
+
+//Close does nothing, the body is in memory.
+func (bufferedBody) Close() error{
+	return nil
+}
+
+//readBody reads the whole body of the request so that it can be read again by getAllJSONdata.
+//It writes 413 and returns false if the body is larger than MaxUploadSize.
+func readBody(w http.ResponseWriter, r *http.Request) bool{
+	if r.Body == nil{
+		return true
+	}
+	if _, ok := r.Body.(bufferedBody); ok{
+		return true
+	}
+	maxSize := *stateOf(r).maxUploadSize
+	if r.ContentLength > maxSize{
+		http.Error(w,fmt.Sprintf("Request body is larger than the limit of %d bytes",maxSize),http.StatusRequestEntityTooLarge)
+		return false
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w,r.Body,maxSize))
+	if err != nil{
+		http.Error(w,fmt.Sprintf("Request body is larger than the limit of %d bytes",maxSize),http.StatusRequestEntityTooLarge)
+		return false
 	}
-	return getData
+	r.Body = bufferedBody{bytes.NewReader(body)}
+	return true
 }
 
-func initFilemap(r *http.Request) jsonMap{
-	fileMap := jsonMap{}
-	r.ParseMultipartForm(256)	
-	if r.MultipartForm  == nil {
-		return fileMap
+//uploadedFiles returns every file of a multipart/form-data request, ordered by field name and then by their order in the request.
+func uploadedFiles(r *http.Request) []uploadedFile{
+	//The body is already in memory, so the files are kept there too instead of being written to temporary files.
+	r.ParseMultipartForm(*stateOf(r).maxUploadSize)
+	if r.MultipartForm == nil{
+		return nil
 	}
+	fields := make([]string,0,len(r.MultipartForm.File))
 	for k := range r.MultipartForm.File{
-		var buf bytes.Buffer
-		file,header,_ := r.FormFile(k)
-		io.Copy(&buf,file)
-		fileMap[header.Filename] = buf.String()
+		fields = append(fields,k)
+	}
+	sort.Strings(fields)
+	var files []uploadedFile
+	for _,k := range fields{
+		for _,header := range r.MultipartForm.File[k]{
+			file, err := header.Open()
+			if err != nil{
+				continue
+			}
+			data, _ := ioutil.ReadAll(file)
+			file.Close()
+			sum := sha256.Sum256(data)
+			contentType := header.Header.Get("Content-Type")
+			if contentType == ""{
+				contentType = "application/octet-stream"
+			}
+			files = append(files,uploadedFile{api.Upload{Field: k, Filename: header.Filename, ContentType: contentType, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}, data})
+		}
 	}
-	return fileMap
+	return files
 }
 
-func initFormMap(r *http.Request) jsonMap {
-	r.ParseForm()	
-	formMap := jsonMap{}
-	for k,v := range r.Form{
-		formMap[k] = v[0]
+//fileContent returns the content of the file as a string if it is text, otherwise as a base64 data URL.
+func fileContent(file uploadedFile) string{
+	if utf8.Valid(file.data) && bytes.IndexByte(file.data,0) < 0{
+		return string(file.data)
+	}
+	return "data:"+file.ContentType+";base64,"+base64.StdEncoding.EncodeToString(file.data)
+}
+
+//bodyData returns the raw body for the "data" field, like fileContent does for files.
+//Form bodies are echoed in the "form" and "files" fields instead, so their data is empty.
+func bodyData(r *http.Request, body []byte) string{
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" || mediaType == "application/x-www-form-urlencoded"{
+		return ""
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == ""{
+		contentType = "application/octet-stream"
 	}
-	return formMap
+	return fileContent(uploadedFile{Upload: api.Upload{ContentType: contentType}, data: body})
+}
+
+//initFilemap returns the contents of the uploaded files by their form field names.
+//A field with several files maps to an array of their contents.
+func initFilemap(r *http.Request) api.Values{
+	contents := api.Values{}
+	for _,file := range uploadedFiles(r){
+		contents[file.Field] = append(contents[file.Field],fileContent(file))
+	}
+	return contents
+}
+
+//initUploads returns the metadata of the uploaded files.
+func initUploads(r *http.Request) []api.Upload{
+	uploads := []api.Upload{}
+	for _,file := range uploadedFiles(r){
+		uploads = append(uploads,file.Upload)
+	}
+	return uploads
+}
+
+func initFormMap(r *http.Request) api.Values {
+	r.ParseForm()	
+	return api.Values(r.Form)
 }
 func setCooki(w http.ResponseWriter, r * http.Request) jsonMap{
 	cookieMap :=jsonMap{}
@@ -112,36 +248,44 @@ func delCooki(w http.ResponseWriter, r * http.Request){
 	}
 
 }
-func getMapForJSON(str string) map[string]interface{}{
-	var vals []string
-	resp := make(map[string]interface{})
-	vals = nil
-	for _,v:= range str{
-		switch v{
-		case ':':
-			vals = strings.Split(str,string(v))
-			break
-		case '=':
-			vals = strings.Split(str,string(v))
-			break
-		}
+//maxJSONSize is the largest body in bytes which is decoded for the "json" field of echo responses.
+var maxJSONSize int64 = 1 << 20
+
+//isJSONContentType reports whether the media type of a Content-Type header is JSON, like application/json, text/json or application/*+json.
+func isJSONContentType(contentType string) bool{
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil{
+		return false
 	}
-	if vals == nil {
-		return nil
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType,"+json")
+}
+
+//decodeJSON decodes the body of the request as a single JSON value.
+//It returns nil without an error if the Content-Type of the request is not JSON or the body is empty.
+//Numbers are kept as json.Number so that large integers are echoed back exactly.
+func decodeJSON(r *http.Request, body []byte) (interface{}, error){
+	if !isJSONContentType(r.Header.Get("Content-Type")) || len(bytes.TrimSpace(body)) == 0{
+		return nil, nil
+	}
+	if int64(len(body)) > maxJSONSize{
+		return nil, fmt.Errorf("JSON body is %d bytes, larger than the limit of %d bytes",len(body),maxJSONSize)
 	}
-	for i:=0;i<len(vals)-1;i+=2{
-		log.Println(vals[i])
-		log.Println(vals[i+1])
-		vals[i] = strings.TrimLeft(vals[i],"{")
-		vals[i+1] = strings.TrimRight(vals[i+1],"}")
-		resp[vals[i]] = vals[i+1]
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil{
+		return nil, fmt.Errorf("invalid JSON body: %v",err)
 	}
-	//log.Println(vals)
-	//log.Println(resp)
-	return resp
+	if _, err := dec.Token(); err != io.EOF{
+		return nil, errors.New("invalid JSON body: unexpected data after the JSON value")
+	}
+	return val, nil
 }
+
+//getAllJSONdata fills the fields of api.EchoResponse which are named by keys, their JSON names, from the request.
+//It returns only those fields, and "error" if the body could not be decoded for "json".
 func getAllJSONdata(r *http.Request, keys ...string) jsonMap{
-	jsonData := jsonMap{}
+	var echo api.EchoResponse
 	var body []byte
 	if r.Body == nil{
 		body = []byte("")
@@ -150,52 +294,476 @@ func getAllJSONdata(r *http.Request, keys ...string) jsonMap{
 		r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 	}
 	
-	//body :="repair"
 	for _, key := range keys{
 		switch key {
 		case "headers":
-			jsonData["headers"] = initHeadMap(r,body)
+			echo.Headers = initHeadMap(r,body)
 		case "origin":
-			jsonData["origin"] = r.Header.Get("X-Forwarded-For")
+			echo.Origin = clientIP(r)
 		case "url":
-			jsonData["url"] = r.Host+r.URL.String()
+			echo.URL = requestURL(r)
 		case "json":
-			js := getMapForJSON(string(body))
-			jsonData["json"] = strings.TrimRight(string(makeJSONresponse(js)),"\n")
+			val, err := decodeJSON(r,body)
+			echo.JSON, _ = json.Marshal(val)
+			if err != nil{
+				echo.Error = err.Error()
+				keys = append(keys,"error")
+			}
 		case "method":
-			jsonData["method"] = r.Method
+			echo.Method = r.Method
+		case "protocol":
+			echo.Protocol = r.Proto
 		case "args":
-			jsonData["args"] = initQueryMap(r)
+			echo.Args = initQueryMap(r)
 		case "user-agent":
-			jsonData["user-agent"] = r.Header.Get("user-agent")
+			echo.UserAgent = r.Header.Get("user-agent")
 		case "uuid":
-			jsonData["uuid"], _ = exec.Command("uuidgen").Output()
+			out, _ := exec.Command("uuidgen").Output()
+			echo.UUID = strings.TrimSpace(string(out))
 		case "form":
-			jsonData["form"] = initFormMap(r)
+			echo.Form = initFormMap(r)
 		case "files":
-			jsonData["files"] = initFilemap(r)
+			echo.Files = initFilemap(r)
+		case "uploads":
+			echo.Uploads = initUploads(r)
 		case "data":
-			jsonData["data"] = string(body) //if data can be encoded json,then encode it as json.
+			echo.Data = bodyData(r,body)
 		case "brotli":
-			jsonData["brotli"] = true//search
+			echo.Brotli = true
 		case "deflated":
-			jsonData["deflated"] = true//search
+			echo.Deflated = true
 		case "gzipped":
-			jsonData["gzipped"] = true//search
+			echo.Gzipped = true
 		case "authenticated":
-			jsonData["authenticated"] = true
+			echo.Authenticated = true
 		case "user":
-			if len(r.URL.String())==12{
-				jsonData["user"] = "user"
-			} else{
-				stripUser := r.URL.String()[len("/basic-auth/"):]
-				jsonData["user"] = stripUser[:strings.Index(stripUser,"/")]
-			}
-		
+			echo.User = pathParam(r,"user")
+		}
+	}
+	return selectFields(echo,keys...)
+}
+
+//selectFields returns the fields of v which are named by keys, their JSON names, already encoded.
+func selectFields(v interface{}, keys ...string) jsonMap{
+	encoded, _ := json.Marshal(v)
+	var fields map[string]json.RawMessage
+	json.Unmarshal(encoded,&fields)
+	jsonData := jsonMap{}
+	for _, key := range keys{
+		if field, ok := fields[key]; ok{
+			jsonData[key] = field
 		}
 	}
 	return jsonData
 }
+//queryInt returns the integer value of the given query parameter or def if it is not provided.
+func queryInt(r *http.Request, key string, def int) (int, error){
+	val := r.URL.Query().Get(key)
+	if val == ""{
+		return def, nil
+	}
+	return strconv.Atoi(val)
+}
+
+//queryFloat returns the float value of the given query parameter or def if it is not provided.
+func queryFloat(r *http.Request, key string, def float64) (float64, error){
+	val := r.URL.Query().Get(key)
+	if val == ""{
+		return def, nil
+	}
+	return strconv.ParseFloat(val,64)
+}
+
+//sleepContext waits for d and reports false if the request is cancelled before.
+func sleepContext(r *http.Request, d time.Duration) bool{
+	if d <= 0{
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select{
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+//byteRange is an inclusive range of bytes requested with a Range header.
+type byteRange struct{
+	start, end int
+}
+
+//contentRange returns the value of Content-Range header of the range for a resource with given size.
+func (br byteRange) contentRange(size int) string{
+	return fmt.Sprintf("bytes %d-%d/%d",br.start,br.end,size)
+}
+
+//errUnsatisfiableRange is returned by parseByteRanges when none of the ranges overlaps the resource.
+var errUnsatisfiableRange = errors.New("range not satisfiable")
+
+//maxByteRanges is the largest number of ranges of a Range header which is not ignored.
+const maxByteRanges = 100
+
+//parseByteRanges parses a Range header for a resource with given size.
+//A nil result without error means the header is absent or malformed and must be ignored (RFC 7233, 3.1).
+//Like http.ServeContent, it ignores more than maxByteRanges ranges and ranges which add up to more than the resource,
+//so that overlapping ranges cannot make a response many times larger than the resource.
+func parseByteRanges(header string, size int) ([]byteRange, error){
+	if !strings.HasPrefix(header,"bytes="){
+		return nil, nil
+	}
+	var ranges []byteRange
+	specs := 0
+	for _,spec := range strings.Split(header[len("bytes="):],","){
+		spec = strings.TrimSpace(spec)
+		if spec == ""{
+			continue
+		}
+		specs++
+		if specs > maxByteRanges{
+			return nil, nil
+		}
+		dash := strings.Index(spec,"-")
+		if dash < 0{
+			return nil, nil
+		}
+		first, last := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+		var br byteRange
+		if first == ""{
+			//suffix range: the last n bytes
+			n, err := strconv.Atoi(last)
+			if err != nil || n < 0{
+				return nil, nil
+			}
+			if n == 0{
+				continue
+			}
+			if n > size{
+				n = size
+			}
+			br = byteRange{size-n,size-1}
+		}else{
+			start, err := strconv.Atoi(first)
+			if err != nil || start < 0{
+				return nil, nil
+			}
+			end := size-1
+			if last != ""{
+				end, err = strconv.Atoi(last)
+				if err != nil || end < start{
+					return nil, nil
+				}
+			}
+			if start >= size{
+				continue
+			}
+			if end >= size{
+				end = size-1
+			}
+			br = byteRange{start,end}
+		}
+		ranges = append(ranges,br)
+	}
+	if specs == 0{
+		return nil, nil
+	}
+	if len(ranges) == 0{
+		return nil, errUnsatisfiableRange
+	}
+	total := 0
+	for _,br := range ranges{
+		total += br.end-br.start+1
+	}
+	if total > size{
+		return nil, nil
+	}
+	return ranges, nil
+}
+
+//writePaced writes body in chunkSize pieces spread over duration, flushing after each piece.
+//It stops writing if the request is cancelled.
+func writePaced(w http.ResponseWriter, r *http.Request, body []byte, chunkSize int, duration time.Duration){
+	if chunkSize <= 0{
+		chunkSize = len(body)
+	}
+	chunks := (len(body)+chunkSize-1)/chunkSize
+	var pause time.Duration
+	if chunks > 0{
+		pause = duration/time.Duration(chunks)
+	}
+	flusher, _ := w.(http.Flusher)
+	for i:=0;i<len(body);i+=chunkSize{
+		if i > 0 && !sleepContext(r,pause){
+			return
+		}
+		end := i+chunkSize
+		if end > len(body){
+			end = len(body)
+		}
+		w.Write(body[i:end])
+		if flusher != nil{
+			flusher.Flush()
+		}
+	}
+}
+
+//parseETags splits an If-Match or If-None-Match header into its entity tags.
+//Commas inside of quoted tags do not separate tags.
+func parseETags(header string) []string{
+	var etags []string
+	inQuote := false
+	start := 0
+	for i:=0;i<=len(header);i++{
+		if i < len(header) && (header[i] != ',' || inQuote){
+			if header[i] == '"'{
+				inQuote = !inQuote
+			}
+			continue
+		}
+		if tag := strings.TrimSpace(header[start:i]); tag != ""{
+			etags = append(etags,tag)
+		}
+		start = i+1
+	}
+	return etags
+}
+
+//etagMatch reports whether etag matches one of the tags of the header.
+//Weak comparison ignores the W/ prefix, strong comparison never matches a weak tag (RFC 7232, 2.3.2).
+func etagMatch(header, etag string, weak bool) bool{
+	for _,tag := range parseETags(header){
+		if tag == "*"{
+			return true
+		}
+		if weak{
+			if strings.TrimPrefix(tag,"W/") == strings.TrimPrefix(etag,"W/"){
+				return true
+			}
+		}else if !strings.HasPrefix(tag,"W/") && !strings.HasPrefix(etag,"W/") && tag == etag{
+			return true
+		}
+	}
+	return false
+}
+
+//checkPreconditions evaluates conditional request headers for a resource with given ETag and modification time in RFC 7232 order.
+//If the request is already answered with 304 or 412 it returns true.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool{
+	lastModified = lastModified.Truncate(time.Second)
+	if im := r.Header.Get("If-Match"); im != ""{
+		if !etagMatch(im,etag,false){
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return true
+		}
+	}else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && !lastModified.IsZero(){
+		if t, err := http.ParseTime(ius); err == nil && lastModified.After(t){
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return true
+		}
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != ""{
+		if etagMatch(inm,etag,true){
+			if r.Method == "GET" || r.Method == "HEAD"{
+				w.WriteHeader(http.StatusNotModified)
+			}else{
+				w.WriteHeader(http.StatusPreconditionFailed)
+			}
+			return true
+		}
+	}else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() && (r.Method == "GET" || r.Method == "HEAD"){
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t){
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+//randomSource returns a random generator seeded with the seed parameter of the request.
+//Without a seed parameter it is seeded with the current time.
+func randomSource(r *http.Request) (*rand.Rand, error){
+	seed := time.Now().UnixNano()
+	if val := r.URL.Query().Get("seed"); val != ""{
+		var err error
+		seed, err = strconv.ParseInt(val,10,64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rand.New(rand.NewSource(seed)), nil
+}
+
+//redirectChain redirects the request for the n path parameter to next+(n-1), or to /get when n is 1.
+//Location is an absolute URL if absolute is true, otherwise it is relative to the host.
+func redirectChain(w http.ResponseWriter, r *http.Request, next string, absolute bool){
+	n := intParam(r,"n")
+	if n < 0{
+		http.Error(w,"Invalid n",http.StatusBadRequest)
+		return
+	}
+	location := "/get"
+	if n > 1{
+		location = next+strconv.Itoa(n-1)
+	}
+	if absolute{
+		location = absoluteURL(r,location)
+	}
+	http.Redirect(w,r,location,http.StatusFound)
+}
+
+//chooseStatus parses a comma separated list of status codes with optional weights like "200:0.8,500:0.2"
+//and chooses one of them randomly according to the weights. Codes without a weight have the weight 1.
+func chooseStatus(codes string) (int, error){
+	var choices []int
+	var weights []float64
+	total := 0.0
+	for _,choice := range strings.Split(codes,","){
+		weight := 1.0
+		if colon := strings.Index(choice,":"); colon >= 0{
+			var err error
+			weight, err = strconv.ParseFloat(choice[colon+1:],64)
+			if err != nil || weight < 0{
+				return 0, errors.New("invalid weight")
+			}
+			choice = choice[:colon]
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(choice))
+		if err != nil || code < 200 || code > 599{
+			return 0, errors.New("invalid status code")
+		}
+		choices = append(choices,code)
+		weights = append(weights,weight)
+		total += weight
+	}
+	if total <= 0{
+		return 0, errors.New("invalid weights")
+	}
+	x := rand.Float64()*total
+	for i,weight := range weights{
+		if x < weight{
+			return choices[i], nil
+		}
+		x -= weight
+	}
+	return choices[len(choices)-1], nil
+}
+
+//teapot is the body of 418 I'm a teapot responses.
+const teapot = "\n"+
+	"    -=[ teapot ]=-\n"+
+	"\n"+
+	"       _...._\n"+
+	"     .'  _ _ `.\n"+
+	"    | .\"` ^ `\". _,\n"+
+	"    \\_;`\"---\"`|//\n"+
+	"      |       ;/\n"+
+	"      \\_     _/\n"+
+	"        `\"\"\"`\n"
+
+//writeStatus writes the response of given status code, some codes have their special headers and bodies.
+func writeStatus(w http.ResponseWriter, code int){
+	switch code{
+	case 301, 302, 303, 305, 307:
+		w.Header().Set("Location","/redirect/1")
+		w.WriteHeader(code)
+	case 401:
+		w.Header().Set("WWW-Authenticate",`Basic realm="Fake Realm"`)
+		w.WriteHeader(code)
+	case 402:
+		w.Header().Set("X-More-Info","http://vimeo.com/22053820")
+		w.WriteHeader(code)
+		w.Write([]byte("Pay me!"))
+	case 406:
+		jsonData := jsonMap{}
+		jsonData["message"] = "Client did not request a supported media type."
+		jsonData["accept"] = []string{"image/webp","image/svg+xml","image/jpeg","image/png","image/*"}
+		writeJSON(w,code,jsonData)
+	case 407:
+		w.Header().Set("Proxy-Authenticate",`Basic realm="Fake Realm"`)
+		w.WriteHeader(code)
+	case 418:
+		w.Header().Set("X-More-Info","http://tools.ietf.org/html/rfc2324")
+		w.WriteHeader(code)
+		w.Write([]byte(teapot))
+	default:
+		w.WriteHeader(code)
+	}
+}
+
+//imageFormat is an embedded image with its media type.
+type imageFormat struct{
+	contentType string
+	data []byte
+}
+
+//imageFormats are the images served by the /image endpoints, in order of preference when the client has none.
+var imageFormats = []imageFormat{
+	{"image/png",templates.PngImage},
+	{"image/jpeg",templates.JpegImage},
+	{"image/webp",templates.WebpImage},
+	{"image/svg+xml",templates.SvgImage},
+}
+
+//writeImage sends the image with its Content-Type and Content-Length headers.
+func writeImage(w http.ResponseWriter, img imageFormat){
+	w.Header().Set("Content-Type",img.contentType)
+	w.Header().Set("Content-Length",strconv.Itoa(len(img.data)))
+	w.Write(img.data)
+}
+
+//negotiateImage chooses the image format with the highest quality value in the Accept header (RFC 7231, 5.3.2).
+//Quality ties are broken by the more specific media range and then by the order of imageFormats.
+//An empty Accept header accepts every format.
+func negotiateImage(accept string) (imageFormat, bool){
+	if strings.TrimSpace(accept) == ""{
+		accept = "*/*"
+	}
+	type mediaRange struct{
+		mediaType string
+		q float64
+	}
+	var ranges []mediaRange
+	for _,part := range strings.Split(accept,","){
+		params := strings.Split(part,";")
+		mr := mediaRange{strings.ToLower(strings.TrimSpace(params[0])),1}
+		for _,param := range params[1:]{
+			kv := strings.SplitN(strings.TrimSpace(param),"=",2)
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q"{
+				if q, err := strconv.ParseFloat(kv[1],64); err == nil{
+					mr.q = q
+				}
+			}
+		}
+		ranges = append(ranges,mr)
+	}
+	best, bestQ, bestSpecificity := -1, 0.0, -1
+	for i,img := range imageFormats{
+		q, specificity := 0.0, -1
+		for _,mr := range ranges{
+			s := -1
+			switch{
+			case mr.mediaType == img.contentType:
+				s = 2
+			case mr.mediaType == img.contentType[:strings.Index(img.contentType,"/")]+"/*":
+				s = 1
+			case mr.mediaType == "*/*":
+				s = 0
+			}
+			if s > specificity{
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity){
+			best, bestQ, bestSpecificity = i, q, specificity
+		}
+	}
+	if best < 0{
+		return imageFormat{}, false
+	}
+	return imageFormats[best], true
+}
+
 func check(username,password string,r *http.Request) bool{
 	user,passwd,ok := r.BasicAuth()
 	return user == username && password == passwd && ok
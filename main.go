@@ -1,21 +1,50 @@
 //responsiveweb project is inspired by Kenneth Reitz's https://httpbin.org project.
 //It is implemented with built-in HTTP library.
-//All handlers will be registered to the DefaultServeMux.
+//All endpoints are served by a handler of the handlers package.
+//The server is configured by the config package from a file, environment variables and flags.
+//HTTPS is served with the given certificate or a self-signed one when a TLS address is configured.
+//On SIGINT or SIGTERM it stops accepting connections and drains in-flight requests before exiting.
 package main
 
 import(
+	"context"
+	"crypto/tls"
+	"flag"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"github.com/tahasevim/responsiveweb/config"
 	"github.com/tahasevim/responsiveweb/handlers"
-	"flag"
+	"github.com/tahasevim/responsiveweb/server"
 )
 func main(){
-	p := flag.String("port","8080","holds port")
-	flag.Parse()
-	handlerList := handlers.GetHandlers()
-	for url, handlerFunc := range handlerList{
-		http.HandleFunc(url,handlerFunc)
+	cfg, err := config.Load(os.Args[0],os.Args[1:])
+	if err == flag.ErrHelp{
+		return
+	}
+	if err != nil{
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(),os.Interrupt,syscall.SIGTERM)
+	defer stop()
+	opts := cfg.Options()
+	var tlsConfig *tls.Config
+	if cfg.TLS.Address != ""{
+		if tlsConfig, err = cfg.TLSConfig(); err != nil{
+			log.Fatal(err)
+		}
+		opts = append(opts,handlers.WithClientCAs(tlsConfig.ClientCAs))
+		log.Println("HTTPS started to listening at: "+ cfg.TLS.Address)
+		if cfg.TLS.CertFile == ""{
+			log.Println("Clients can trust the self-signed CA in "+ filepath.Join(cfg.TLS.CertDir,server.CAFile))
+		}
+	}
+	srv := cfg.Server(handlers.New(opts...))
+	srv.TLSConfig = tlsConfig
+	log.Println("Server started to listening at: "+ srv.Addr)
+	if err := server.ListenAndServe(ctx,srv,cfg.TLS.Address,cfg.ShutdownPolicy()); err != nil{
+		log.Fatal(err)
 	}
-	log.Println("Server started to listening at port: "+ *p)
-	log.Println(http.ListenAndServe(":"+*p,nil))
 }
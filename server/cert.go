@@ -0,0 +1,188 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//The files which LoadOrCreateCertificates keeps in its directory.
+//Clients trust the server by trusting CAFile.
+const (
+	CAFile    = "ca.pem"
+	CAKeyFile = "ca-key.pem"
+	CertFile  = "cert.pem"
+	KeyFile   = "key.pem"
+)
+
+//LoadOrCreateCertificates returns the server certificate in dir, creating a self-signed CA and a leaf certificate for hosts signed by it if they do not exist.
+//hosts are DNS names or IP addresses. The certificates are written to dir so that clients can trust the CA and restarts keep the same certificates.
+//The leaf certificate is created again if it has expired, was made for other hosts or was not signed by the CA in dir.
+//It is signed by the existing CA as long as that is valid, a new CA is created otherwise.
+func LoadOrCreateCertificates(dir string, hosts []string) (tls.Certificate, error){
+	certFile, keyFile := filepath.Join(dir, CertFile), filepath.Join(dir, KeyFile)
+	if len(hosts) == 0 {
+		hosts = []string{"localhost"}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	ca, caKey, err := loadCA(dir, now)
+	if err != nil {
+		if ca, caKey, err = createCA(dir, now); err != nil {
+			return tls.Certificate{}, err
+		}
+	} else if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		//Clients which trust CAFile can only verify a leaf which it signed.
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err == nil && now.Before(leaf.NotAfter) && sameHosts(leaf, hosts) && leaf.CheckSignatureFrom(ca) == nil {
+			return cert, nil
+		}
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{Organization: []string{"responsiveweb"}, CommonName: hosts[0]},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			leafTemplate.IPAddresses = append(leafTemplate.IPAddresses, ip)
+		} else {
+			leafTemplate.DNSNames = append(leafTemplate.DNSNames, host)
+		}
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	//The leaf file holds the whole chain so that it is sent to clients.
+	if err := writePEM(filepath.Join(dir, CertFile), 0644, &pem.Block{Type: "CERTIFICATE", Bytes: leafDER}, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writePEM(filepath.Join(dir, KeyFile), 0600, ecKeyBlock(leafKey)); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+//loadCA returns the CA in dir if it is still valid at now.
+func loadCA(dir string, now time.Time) (*x509.Certificate, *ecdsa.PrivateKey, error){
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, CAFile), filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok || !ca.IsCA || !now.Before(ca.NotAfter) {
+		return nil, nil, fmt.Errorf("the CA in %s cannot be used", dir)
+	}
+	return ca, key, nil
+}
+
+//createCA creates a self-signed CA in dir.
+func createCA(dir string, now time.Time) (*x509.Certificate, *ecdsa.PrivateKey, error){
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{Organization: []string{"responsiveweb"}, CommonName: "responsiveweb CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(filepath.Join(dir, CAFile), 0644, &pem.Block{Type: "CERTIFICATE", Bytes: caDER}); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(filepath.Join(dir, CAKeyFile), 0600, ecKeyBlock(caKey)); err != nil {
+		return nil, nil, err
+	}
+	return ca, caKey, nil
+}
+
+//sameHosts reports whether the DNS names and IP addresses of the certificate are hosts, in any order.
+func sameHosts(cert *x509.Certificate, hosts []string) bool{
+	names := map[string]bool{}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			host = ip.String()
+		}
+		names[host] = true
+	}
+	certNames := map[string]bool{}
+	for _, name := range cert.DNSNames {
+		certNames[name] = true
+	}
+	for _, ip := range cert.IPAddresses {
+		certNames[ip.String()] = true
+	}
+	if len(names) != len(certNames) {
+		return false
+	}
+	for name := range names {
+		if !certNames[name] {
+			return false
+		}
+	}
+	return true
+}
+
+//writePEM writes the blocks to the file.
+func writePEM(file string, perm os.FileMode, blocks ...*pem.Block) error{
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	return ioutil.WriteFile(file, data, perm)
+}
+
+//randomSerial returns a random 128 bit certificate serial number.
+func randomSerial() *big.Int{
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
+
+//ecKeyBlock returns the PEM block of an EC private key.
+func ecKeyBlock(key *ecdsa.PrivateKey) *pem.Block{
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(fmt.Sprintf("marshaling a generated key: %v", err))
+	}
+	return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+}
@@ -0,0 +1,154 @@
+package server
+
+import(
+	"testing"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func TestLoadOrCreateCertificates(t *testing.T){
+	dir, err := ioutil.TempDir("","certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert, err := LoadOrCreateCertificates(dir,[]string{"localhost","127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPEM, err := ioutil.ReadFile(filepath.Join(dir,CAFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _,host := range []string{"localhost","127.0.0.1"}{
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots:roots,DNSName:host}); err != nil {
+			t.Errorf("Certificate is not valid for %v:%v",host,err)
+		}
+	}
+
+	again, err := LoadOrCreateCertificates(dir,[]string{"127.0.0.1","localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.Certificate[0]) != string(cert.Certificate[0]){
+		t.Error("Existing certificate was not reused")
+	}
+
+	//Other hosts get a new certificate signed by the same CA.
+	other, err := LoadOrCreateCertificates(dir,[]string{"example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(other.Certificate[0]) == string(cert.Certificate[0]){
+		t.Error("Certificate was reused for other hosts")
+	}
+	otherLeaf, err := x509.ParseCertificate(other.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := otherLeaf.Verify(x509.VerifyOptions{Roots:roots,DNSName:"example.com"}); err != nil {
+		t.Errorf("New certificate is not signed by the existing CA:%v",err)
+	}
+}
+
+func TestLoadOrCreateCertificatesExpired(t *testing.T){
+	dir, err := ioutil.TempDir("","certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hosts := []string{"localhost"}
+	ca, caKey, err := createCA(dir,time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(),rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		DNSNames: hosts,
+		NotBefore: time.Now().Add(-48*time.Hour),
+		NotAfter: time.Now().Add(-24*time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader,expired,ca,&key.PublicKey,caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writePEM(filepath.Join(dir,CertFile),0644,&pem.Block{Type:"CERTIFICATE",Bytes:der}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePEM(filepath.Join(dir,KeyFile),0600,ecKeyBlock(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := LoadOrCreateCertificates(dir,hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !time.Now().Before(leaf.NotAfter){
+		t.Errorf("Expired certificate was reused, it expired at %v",leaf.NotAfter)
+	}
+}
+
+func TestLoadOrCreateCertificatesCAChanged(t *testing.T){
+	dir, err := ioutil.TempDir("","certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hosts := []string{"localhost"}
+	if _, err := LoadOrCreateCertificates(dir,hosts); err != nil {
+		t.Fatal(err)
+	}
+	//The CA is deleted once, then it is replaced by another one.
+	for _,change := range []func() error{
+		func() error{ return os.Remove(filepath.Join(dir,CAFile)) },
+		func() error{
+			_, _, err := createCA(dir,time.Now())
+			return err
+		},
+	}{
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+		cert, err := LoadOrCreateCertificates(dir,hosts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caPEM, err := ioutil.ReadFile(filepath.Join(dir,CAFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(caPEM)
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := leaf.CheckSignatureFrom(ca); err != nil {
+			t.Errorf("Certificate is not signed by %v:%v",CAFile,err)
+		}
+	}
+}
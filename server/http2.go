@@ -0,0 +1,499 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/tahasevim/responsiveweb/handlers"
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/http2/hpack"
+)
+
+//h2cUpgradeResponse is what the h2c handler writes before it speaks HTTP/2 on an upgraded connection.
+const h2cUpgradeResponse = "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"
+
+//maxSniffedStreams is how many of the latest streams of a connection are remembered for StreamID.
+const maxSniffedStreams = 256
+
+//maxSniffedFrame is the largest SETTINGS or HEADERS frame which is parsed, the connection is not sniffed anymore after a larger one.
+const maxSniffedFrame = 1 << 20
+
+//maxPendingHeaderBytes is how many bytes of header blocks a connection keeps undecoded until /http2 is requested on it.
+const maxPendingHeaderBytes = 64 << 10
+
+//frameConnKey is the context key of the frameConn of a cleartext request.
+type frameConnKey struct{}
+
+//configureHTTP2 enables HTTP/2 on srv over TLS and in cleartext by prior knowledge or an h2c Upgrade.
+//The HTTP/2 connections are served through frameConns so that /http2 can report their stream IDs and SETTINGS,
+//the cleartext listener must be wrapped by frameListener for that.
+//The stream IDs are only known on connections which request /http2 before they opened maxSniffedStreams streams.
+//The body of an Upgrade request is read whole before the upgrade, so a body over maxUploadSize bytes is answered with 413.
+//They are served by the returned server, which only has to be shut down to send GOAWAY on them.
+//It is not srv itself, because net/http bypasses TLSNextProto on a server which was configured for HTTP/2.
+func configureHTTP2(srv *http.Server, maxUploadSize int64) (*http.Server, error){
+	h2srv := &http.Server{
+		ReadTimeout:       srv.ReadTimeout,
+		ReadHeaderTimeout: srv.ReadHeaderTimeout,
+		WriteTimeout:      srv.WriteTimeout,
+		IdleTimeout:       srv.IdleTimeout,
+		MaxHeaderBytes:    srv.MaxHeaderBytes,
+		ErrorLog:          srv.ErrorLog,
+	}
+	h2s := &http2.Server{}
+	if err := http2.ConfigureServer(h2srv, h2s); err != nil {
+		return nil, err
+	}
+
+	if srv.TLSConfig != nil && !hasProto(srv.TLSConfig.NextProtos, http2.NextProtoTLS) {
+		srv.TLSConfig = srv.TLSConfig.Clone()
+		srv.TLSConfig.NextProtos = append([]string{http2.NextProtoTLS}, srv.TLSConfig.NextProtos...)
+	}
+	if srv.TLSNextProto == nil {
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	srv.TLSNextProto[http2.NextProtoTLS] = func(hs *http.Server, c *tls.Conn, h http.Handler){
+		conn := &tlsFrameConn{newFrameConn(c, true), c}
+		ctx := context.Background()
+		if bc, ok := h.(interface{ BaseContext() context.Context }); ok {
+			ctx = bc.BaseContext()
+		}
+		h2s.ServeConn(conn, &http2.ServeConnOpts{Context: handlers.WithHTTP2Conn(ctx, conn.frameConn), Handler: h})
+	}
+
+	handler := srv.Handler
+	cleartext := h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		//The upgraded request is served on stream 1 with the version of the HTTP/1.1 request it was sent as.
+		if conn, ok := r.Context().Value(frameConnKey{}).(*frameConn); ok && r.ProtoMajor == 1 && conn.isHTTP2() {
+			upgraded := *r
+			upgraded.Proto, upgraded.ProtoMajor, upgraded.ProtoMinor = "HTTP/2.0", 2, 0
+			r = &upgraded
+		}
+		handler.ServeHTTP(w, r)
+	}), h2s)
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		if r.TLS != nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		if httpguts.HeaderValuesContainsToken(r.Header["Upgrade"], "h2c") {
+			if r.ContentLength > maxUploadSize {
+				http.Error(w, fmt.Sprintf("Request body is larger than the limit of %d bytes", maxUploadSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadSize))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Request body is larger than the limit of %d bytes", maxUploadSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if conn, ok := r.Context().Value(frameConnKey{}).(*frameConn); ok {
+				conn.expectUpgrade(true)
+				defer conn.expectUpgrade(false)
+			}
+		}
+		cleartext.ServeHTTP(w, r)
+	})
+	connContext := srv.ConnContext
+	srv.ConnContext = func(ctx context.Context, c net.Conn) context.Context{
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		if conn, ok := c.(*frameConn); ok {
+			ctx = context.WithValue(handlers.WithHTTP2Conn(ctx, conn), frameConnKey{}, conn)
+		}
+		return ctx
+	}
+	return h2srv, nil
+}
+
+//hasProto reports whether protos contains proto.
+func hasProto(protos []string, proto string) bool{
+	for _, p := range protos {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
+
+//frameListener wraps the connections of a cleartext listener in frameConns.
+type frameListener struct {
+	net.Listener
+}
+
+//Accept returns the next connection wrapped in a frameConn.
+func (ln frameListener) Accept() (net.Conn, error){
+	c, err := ln.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newFrameConn(c, false), nil
+}
+
+//sniffedStream is a stream which was opened by a HEADERS frame of the client.
+type sniffedStream struct {
+	id                      uint32
+	method, path, authority string
+	//upgrade is true for the stream of the request which upgraded the connection to h2c, its headers were not sent in a frame.
+	upgrade bool
+	claimed bool
+}
+
+//frameSniffer parses the HTTP/2 frames sent in one direction of a connection.
+//Only SETTINGS frames and, if onBlock is set, header blocks are parsed, the payloads of the other frames are skipped without being copied.
+type frameSniffer struct {
+	active bool
+	//skip is the number of bytes of the client preface which are left to be skipped.
+	skip int
+	//discard is the number of payload bytes of a skipped frame which are left.
+	discard  int
+	buf      []byte
+	reader   bytes.Reader
+	framer   *http2.Framer
+	settings map[string]uint32
+	block    []byte
+	//onBlock is called with every complete header block and its stream ID.
+	onBlock func(id uint32, block []byte)
+}
+
+//start makes s parse the following bytes, skipping the client preface first if preface is true.
+func (s *frameSniffer) start(preface bool){
+	s.active = true
+	if preface {
+		s.skip = len(http2.ClientPreface)
+	}
+	s.framer = http2.NewFramer(nil, &s.reader)
+	s.framer.SetMaxReadFrameSize(maxSniffedFrame)
+	//The frames between the parsed ones are skipped, so the framer cannot check their order.
+	s.framer.AllowIllegalReads = true
+}
+
+//stop makes s ignore the rest of the connection.
+func (s *frameSniffer) stop(){
+	s.active, s.buf, s.block = false, nil, nil
+}
+
+//wants reports whether frames of the type are parsed.
+func (s *frameSniffer) wants(typ http2.FrameType) bool{
+	switch typ {
+	case http2.FrameSettings:
+		return true
+	case http2.FrameHeaders, http2.FrameContinuation:
+		return s.onBlock != nil
+	}
+	return false
+}
+
+//frameLength returns the payload length of the frame whose header starts buf.
+func frameLength(buf []byte) int{
+	return int(buf[0])<<16 | int(buf[1])<<8 | int(buf[2])
+}
+
+//feed parses the frames which are completed by data.
+//It stops sniffing when the data cannot be parsed, so that a confused sniffer never breaks the connection.
+func (s *frameSniffer) feed(data []byte){
+	if !s.active {
+		return
+	}
+	if s.skip > 0 {
+		n := s.skip
+		if n > len(data) {
+			n = len(data)
+		}
+		s.skip -= n
+		data = data[n:]
+	}
+	for len(data) > 0 && s.active {
+		if s.discard > 0 {
+			n := s.discard
+			if n > len(data) {
+				n = len(data)
+			}
+			s.discard -= n
+			data = data[n:]
+			continue
+		}
+		need := 9
+		if len(s.buf) >= 9 {
+			need += frameLength(s.buf)
+		}
+		n := need - len(s.buf)
+		if n > len(data) {
+			n = len(data)
+		}
+		s.buf = append(s.buf, data[:n]...)
+		data = data[n:]
+		if len(s.buf) < 9 {
+			return
+		}
+		length := frameLength(s.buf)
+		if len(s.buf) == 9 && !s.wants(http2.FrameType(s.buf[3])) {
+			s.buf, s.discard = s.buf[:0], length
+			continue
+		}
+		if length > maxSniffedFrame {
+			s.stop()
+			return
+		}
+		if len(s.buf) < 9+length {
+			continue
+		}
+		s.reader.Reset(s.buf)
+		frame, err := s.framer.ReadFrame()
+		s.buf = s.buf[:0]
+		if err == nil {
+			s.frame(frame)
+		}
+	}
+}
+
+//frame records the SETTINGS and header blocks of a frame.
+func (s *frameSniffer) frame(frame http2.Frame){
+	switch f := frame.(type) {
+	case *http2.SettingsFrame:
+		if f.IsAck() {
+			return
+		}
+		if s.settings == nil {
+			s.settings = map[string]uint32{}
+		}
+		f.ForeachSetting(func(setting http2.Setting) error{
+			s.settings[setting.ID.String()] = setting.Val
+			return nil
+		})
+	case *http2.HeadersFrame:
+		s.block = s.block[:0]
+		s.headerFragment(f.StreamID, f.HeaderBlockFragment(), f.HeadersEnded())
+	case *http2.ContinuationFrame:
+		s.headerFragment(f.StreamID, f.HeaderBlockFragment(), f.HeadersEnded())
+	}
+}
+
+//headerFragment adds a fragment to the header block and hands the block to onBlock once it ended.
+//A block larger than maxPendingHeaderBytes stops the sniffing, so that a flood of CONTINUATION frames is not kept in memory.
+func (s *frameSniffer) headerFragment(id uint32, fragment []byte, ended bool){
+	if len(s.block)+len(fragment) > maxPendingHeaderBytes {
+		s.stop()
+		return
+	}
+	s.block = append(s.block, fragment...)
+	if ended && s.onBlock != nil {
+		s.onBlock(id, s.block)
+	}
+}
+
+//headerBlock is a header block of the client which is not decoded yet.
+type headerBlock struct {
+	id    uint32
+	block []byte
+}
+
+//frameConn is a connection whose HTTP/2 frames are sniffed, it is the handlers.HTTP2Conn of its requests.
+//A cleartext connection is sniffed once it starts with the client preface or is upgraded to h2c.
+//The header blocks of the client are only decoded once /http2 is requested on the connection,
+//so that the others do not decode HPACK twice.
+type frameConn struct {
+	net.Conn
+	mu   sync.Mutex
+	read bool
+	//http2 is true once the connection speaks HTTP/2.
+	http2 bool
+	//upgrading is true while a request for an h2c upgrade is served, only then the writes are searched for the upgrade response.
+	upgrading      bool
+	client, server frameSniffer
+	//decoder decodes the header blocks in pending, it is nil if the streams are not known because too many blocks were pending.
+	decoder      *hpack.Decoder
+	pending      []headerBlock
+	pendingBytes int
+	//eager is true once StreamID was called, the header blocks are decoded as they arrive from then on.
+	eager   bool
+	streams []sniffedStream
+}
+
+//newFrameConn returns a frameConn of c, h2 is true if c speaks HTTP/2 from its first byte.
+func newFrameConn(c net.Conn, h2 bool) *frameConn{
+	conn := &frameConn{Conn: c}
+	if h2 {
+		conn.read = true
+		conn.startHTTP2()
+	}
+	return conn
+}
+
+//startHTTP2 starts sniffing the frames of both sides, the client starts with the preface.
+func (c *frameConn) startHTTP2(){
+	c.http2 = true
+	c.decoder = hpack.NewDecoder(4096, nil)
+	c.client.onBlock = c.addBlock
+	c.client.start(true)
+	c.server.start(false)
+}
+
+//Read reads from the connection and sniffs what the client sent.
+func (c *frameConn) Read(p []byte) (int, error){
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.mu.Lock()
+		if !c.read {
+			c.read = true
+			prefix := p[:n]
+			if len(prefix) > len(http2.ClientPreface) {
+				prefix = prefix[:len(http2.ClientPreface)]
+			}
+			if bytes.HasPrefix([]byte(http2.ClientPreface), prefix) {
+				c.startHTTP2()
+			}
+		}
+		c.client.feed(p[:n])
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+//Write sniffs what the server sends and writes it to the connection.
+//After the h2c upgrade response the client sends the preface and both sides speak HTTP/2.
+func (c *frameConn) Write(p []byte) (int, error){
+	c.mu.Lock()
+	if c.server.active {
+		c.server.feed(p)
+	} else if c.upgrading {
+		if i := bytes.Index(p, []byte(h2cUpgradeResponse)); i >= 0 {
+			c.upgrading = false
+			c.streams = append(c.streams, sniffedStream{id: 1, upgrade: true})
+			c.startHTTP2()
+			c.server.feed(p[i+len(h2cUpgradeResponse):])
+		}
+	}
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+//expectUpgrade sets whether a request for an h2c upgrade is being served on the connection.
+func (c *frameConn) expectUpgrade(upgrading bool){
+	c.mu.Lock()
+	if !c.http2 {
+		c.upgrading = upgrading
+	}
+	c.mu.Unlock()
+}
+
+//isHTTP2 reports whether the connection speaks HTTP/2.
+func (c *frameConn) isHTTP2() bool{
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.http2
+}
+
+//addBlock keeps a header block of the client, it is decoded at once if StreamID was already called.
+func (c *frameConn) addBlock(id uint32, block []byte){
+	if c.decoder == nil {
+		return
+	}
+	c.pending = append(c.pending, headerBlock{id, append([]byte(nil), block...)})
+	c.pendingBytes += len(block)
+	if c.eager {
+		c.decodePending()
+	} else if len(c.pending) > maxSniffedStreams || c.pendingBytes > maxPendingHeaderBytes {
+		c.forgetStreams()
+	}
+}
+
+//forgetStreams stops recording the streams, the HPACK state cannot be followed anymore once a header block was dropped.
+func (c *frameConn) forgetStreams(){
+	c.client.onBlock, c.decoder, c.pending, c.pendingBytes = nil, nil, nil, 0
+}
+
+//decodePending decodes the pending header blocks in order and records the streams which they opened.
+func (c *frameConn) decodePending(){
+	for _, b := range c.pending {
+		fields, err := c.decoder.DecodeFull(b.block)
+		if err != nil {
+			c.forgetStreams()
+			return
+		}
+		c.openStream(b.id, fields)
+	}
+	c.pending, c.pendingBytes = c.pending[:0], 0
+}
+
+//openStream records a stream opened by the client, the later header blocks of a stream are trailers.
+func (c *frameConn) openStream(id uint32, fields []hpack.HeaderField){
+	stream := sniffedStream{id: id}
+	for _, s := range c.streams {
+		if s.id >= id {
+			return
+		}
+	}
+	for _, field := range fields {
+		switch field.Name {
+		case ":method":
+			stream.method = field.Value
+		case ":path":
+			stream.path = field.Value
+		case ":authority":
+			stream.authority = field.Value
+		}
+	}
+	if len(c.streams) == maxSniffedStreams {
+		c.streams = append(c.streams[:0], c.streams[1:]...)
+	}
+	c.streams = append(c.streams, stream)
+}
+
+//StreamID returns the ID of the oldest unclaimed stream whose method, path and authority are those of r.
+//Requests which cannot be told apart get the IDs of their streams in any order.
+func (c *frameConn) StreamID(r *http.Request) (uint32, bool){
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.decoder != nil {
+		c.eager = true
+		c.decodePending()
+	}
+	for _, upgrade := range []bool{false, true} {
+		for i := range c.streams {
+			s := &c.streams[i]
+			if s.claimed || s.upgrade != upgrade {
+				continue
+			}
+			if upgrade || s.method == r.Method && s.path == r.RequestURI && s.authority == r.Host {
+				s.claimed = true
+				return s.id, true
+			}
+		}
+	}
+	return 0, false
+}
+
+//Settings returns copies of the SETTINGS which the client and the server sent.
+func (c *frameConn) Settings() (client, server map[string]uint32){
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	client, server = map[string]uint32{}, map[string]uint32{}
+	for k, v := range c.client.settings {
+		client[k] = v
+	}
+	for k, v := range c.server.settings {
+		server[k] = v
+	}
+	return client, server
+}
+
+//tlsFrameConn is a frameConn of a TLS connection, HTTP/2 takes the TLS state of the requests from its ConnectionState.
+type tlsFrameConn struct {
+	*frameConn
+	tls *tls.Conn
+}
+
+//ConnectionState returns the state of the TLS connection.
+func (c *tlsFrameConn) ConnectionState() tls.ConnectionState{
+	return c.tls.ConnectionState()
+}
@@ -0,0 +1,251 @@
+package server
+
+import(
+	"testing"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"github.com/tahasevim/responsiveweb/handlers"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+//http2Info is the response of /http2.
+type http2Info struct{
+	Protocol string
+	TLS bool
+	StreamID uint32 `json:"stream_id"`
+	Settings map[string]map[string]uint32
+}
+
+//getHTTP2 gets /http2 with client.
+func getHTTP2(t *testing.T, client *http.Client, url string) http2Info{
+	resp, err := client.Get(url+"/http2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var info http2Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestServeH2C(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t,ctx,handlers.NewRouter(),Shutdown{Timeout:time.Second})
+	defer func(){
+		cancel()
+		<-done
+	}()
+	client := &http.Client{Transport:&http2.Transport{
+		AllowHTTP:true,
+		DialTLS:func(network, addr string, cfg *tls.Config) (net.Conn, error){
+			return net.Dial(network,addr)
+		},
+	}}
+	//The client picks the first stream ID, the next request is on the following odd ID of the connection.
+	var last uint32
+	for i := 0; i < 2; i++{
+		info := getHTTP2(t,client,url)
+		if info.Protocol != "HTTP/2.0" || info.TLS || info.StreamID%2 != 1 || last != 0 && info.StreamID != last+2{
+			t.Errorf("Unexpected result:%+v",info)
+		}
+		last = info.StreamID
+		if len(info.Settings["client"]) == 0 || len(info.Settings["server"]) == 0{
+			t.Errorf("SETTINGS were not recorded:%+v",info.Settings)
+		}
+	}
+
+	//HTTP/1 requests still work on the cleartext listener.
+	resp, err := http.Get(url+"/get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var echo map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&echo)
+	resp.Body.Close()
+	if echo["protocol"] != "HTTP/1.1"{
+		t.Errorf("Unexpected protocol:%v",echo["protocol"])
+	}
+}
+
+//readStream reads the frames of fr until stream id ends and returns the body of its response.
+func readStream(t *testing.T, fr *http2.Framer, id uint32) []byte{
+	var body []byte
+	for {
+		frame, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				fr.WriteSettingsAck()
+			}
+		case *http2.DataFrame:
+			if f.StreamID == id {
+				body = append(body,f.Data()...)
+				if f.StreamEnded() {
+					return body
+				}
+			}
+		}
+	}
+}
+
+func TestServeH2CUpgrade(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t,ctx,handlers.NewRouter(),Shutdown{Timeout:time.Second})
+	defer func(){
+		cancel()
+		<-done
+	}()
+	host := strings.TrimPrefix(url,"http://")
+	conn, err := net.Dial("tcp",host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5*time.Second))
+
+	//The HTTP2-Settings header holds the payload of a SETTINGS frame with SETTINGS_MAX_CONCURRENT_STREAMS=100.
+	settings := base64.RawURLEncoding.EncodeToString([]byte{0,3,0,0,0,100})
+	fmt.Fprintf(conn,"GET /http2 HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: %s\r\n\r\n",host,settings)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br,nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols{
+		t.Fatalf("Unexpected status code:%v",resp.StatusCode)
+	}
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatal(err)
+	}
+	fr := http2.NewFramer(conn,br)
+	fr.WriteSettings(http2.Setting{ID:http2.SettingMaxConcurrentStreams,Val:100})
+
+	//The upgraded request is answered on stream 1.
+	var info http2Info
+	if err := json.Unmarshal(readStream(t,fr,1),&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Protocol != "HTTP/2.0" || info.TLS || info.StreamID != 1{
+		t.Errorf("Unexpected result:%+v",info)
+	}
+
+	//The following streams of the connection are recorded too, even those before the next /http2.
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, stream := range []struct{
+		id uint32
+		path string
+	}{{3,"/get"},{5,"/http2"}}{
+		block.Reset()
+		for _, field := range []hpack.HeaderField{{Name:":method",Value:"GET"},{Name:":scheme",Value:"http"},{Name:":authority",Value:host},{Name:":path",Value:stream.path}}{
+			enc.WriteField(field)
+		}
+		fr.WriteHeaders(http2.HeadersFrameParam{StreamID:stream.id,BlockFragment:block.Bytes(),EndStream:true,EndHeaders:true})
+		body := readStream(t,fr,stream.id)
+		if stream.path == "/http2" {
+			info = http2Info{}
+			if err := json.Unmarshal(body,&info); err != nil {
+				t.Fatal(err)
+			}
+			if info.StreamID != stream.id || info.Settings["client"]["MAX_CONCURRENT_STREAMS"] != 100 || len(info.Settings["server"]) == 0{
+				t.Errorf("Unexpected result:%+v",info)
+			}
+		}
+	}
+}
+
+func TestServeH2CUpgradeBodyLimit(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t,ctx,handlers.New(handlers.WithMaxUploadSize(10)),Shutdown{Timeout:time.Second})
+	defer func(){
+		cancel()
+		<-done
+	}()
+	//The body is sent with and without its length, then it is chunked.
+	for _, body := range []io.Reader{strings.NewReader(strings.Repeat("x",100)),io.MultiReader(strings.NewReader(strings.Repeat("x",100)))}{
+		req, _ := http.NewRequest("POST",url+"/post",body)
+		req.Header.Set("Connection","Upgrade, HTTP2-Settings")
+		req.Header.Set("Upgrade","h2c")
+		req.Header.Set("HTTP2-Settings","")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge{
+			t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusRequestEntityTooLarge,resp.StatusCode)
+		}
+	}
+}
+
+func TestFrameSnifferContinuationFlood(t *testing.T){
+	var frames bytes.Buffer
+	fr := http2.NewFramer(&frames,nil)
+	fragment := make([]byte,16<<10)
+	fr.WriteHeaders(http2.HeadersFrameParam{StreamID:1,BlockFragment:fragment})
+	for i := 0; i < 100; i++{
+		fr.WriteContinuation(1,false,fragment)
+	}
+	conn := newFrameConn(nil,true)
+	conn.client.feed([]byte(http2.ClientPreface))
+	conn.client.feed(frames.Bytes())
+	//The header block never ends, the sniffer gives up on it instead of keeping all of it.
+	if conn.client.active || len(conn.client.block) > maxPendingHeaderBytes{
+		t.Errorf("Header block was kept:%v bytes",len(conn.client.block))
+	}
+}
+
+func TestServeHTTP2OverTLS(t *testing.T){
+	dir, err := ioutil.TempDir("","certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert, err := LoadOrCreateCertificates(dir,[]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPEM, _ := ioutil.ReadFile(filepath.Join(dir,CAFile))
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	ln, err := net.Listen("tcp","127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error,1)
+	srv := &http.Server{Handler:handlers.NewRouter(),TLSConfig:&tls.Config{Certificates:[]tls.Certificate{cert}}}
+	go func(){
+		done <- Serve(ctx,srv,nil,ln,Shutdown{Timeout:time.Second})
+	}()
+	defer func(){
+		cancel()
+		<-done
+	}()
+	client := &http.Client{Transport:&http2.Transport{TLSClientConfig:&tls.Config{RootCAs:roots}}}
+	info := getHTTP2(t,client,"https://"+ln.Addr().String())
+	if info.Protocol != "HTTP/2.0" || !info.TLS || info.StreamID != 1 || len(info.Settings["client"]) == 0{
+		t.Errorf("Unexpected result:%+v",info)
+	}
+}
@@ -0,0 +1,151 @@
+//Package server runs the HTTP server and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/tahasevim/responsiveweb/handlers"
+)
+
+//Shutdown is the graceful shutdown policy of Serve.
+type Shutdown struct {
+	//Delay is how long requests are still served after /ready starts answering 503, so that load balancers can notice it.
+	Delay time.Duration
+	//Timeout is how long in-flight requests are waited for before their connections are closed.
+	Timeout time.Duration
+}
+
+//tracker counts the requests which are being served.
+type tracker struct {
+	active int64
+}
+
+//wrap returns a handler which counts its in-flight requests in t.
+func (t *tracker) wrap(h http.Handler) http.Handler{
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		atomic.AddInt64(&t.active, 1)
+		defer atomic.AddInt64(&t.active, -1)
+		h.ServeHTTP(w, r)
+	})
+}
+
+//readySetter is a handler with its own readiness, like those of handlers.New.
+type readySetter interface {
+	SetReady(ready bool)
+}
+
+//uploadLimiter is a handler with its own upload limit, like those of handlers.New.
+type uploadLimiter interface {
+	MaxUploadSize() int64
+}
+
+//Serve serves srv on ln and with TLS on tlsLn until ctx is done and then shuts it down by the policy:
+//it marks the server as not ready, keeps serving for the delay, stops accepting connections
+//and waits for in-flight requests until the timeout, after which the remaining connections are closed.
+//Either listener may be nil, TLS is served with srv.TLSConfig.
+//Both listeners serve HTTP/2 too, the cleartext one by prior knowledge or an h2c Upgrade.
+//The readiness of srv.Handler is set if it has its own, that of the package handlers otherwise.
+//The bodies of h2c Upgrade requests are limited by the upload limit of srv.Handler in the same way.
+//The number of requests which were cut off is logged.
+//If one of the listeners fails, both are closed and its error is returned.
+func Serve(ctx context.Context, srv *http.Server, ln, tlsLn net.Listener, policy Shutdown) error{
+	t := &tracker{}
+	handler := srv.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	setReady := handlers.SetReady
+	if h, ok := handler.(readySetter); ok {
+		setReady = h.SetReady
+	}
+	maxUploadSize := handlers.MaxUploadSize
+	if h, ok := handler.(uploadLimiter); ok {
+		maxUploadSize = h.MaxUploadSize()
+	}
+	srv.Handler = t.wrap(handler)
+	h2srv, err := configureHTTP2(srv, maxUploadSize)
+	if err != nil {
+		return err
+	}
+	setReady(true)
+	errc := make(chan error, 2)
+	serving := 0
+	if ln != nil {
+		serving++
+		go func(){
+			errc <- srv.Serve(frameListener{ln})
+		}()
+	}
+	if tlsLn != nil {
+		serving++
+		go func(){
+			errc <- srv.ServeTLS(tlsLn, "", "")
+		}()
+	}
+	select {
+	case err := <-errc:
+		setReady(false)
+		h2srv.Close()
+		srv.Close()
+		//The other listener returns http.ErrServerClosed once it is closed.
+		for i := 1; i < serving; i++ {
+			<-errc
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	setReady(false)
+	log.Printf("Shutting down, %d requests in flight", atomic.LoadInt64(&t.active))
+	if policy.Delay > 0 {
+		time.Sleep(policy.Delay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), policy.Timeout)
+	defer cancel()
+	//HTTP/2 connections are sent GOAWAY, and the h2c ones, which srv does not track after the upgrade, are waited for by their requests.
+	h2srv.Shutdown(shutdownCtx)
+	err = srv.Shutdown(shutdownCtx)
+	for err == nil && atomic.LoadInt64(&t.active) > 0 {
+		select {
+		case <-shutdownCtx.Done():
+			err = shutdownCtx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if err == context.DeadlineExceeded {
+		cut := atomic.LoadInt64(&t.active)
+		srv.Close()
+		log.Printf("Shutdown timeout of %v exceeded, %d requests were cut off", policy.Timeout, cut)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("All requests were drained")
+	return nil
+}
+
+//ListenAndServe listens on the address of srv and on tlsAddr if it is not empty and calls Serve.
+func ListenAndServe(ctx context.Context, srv *http.Server, tlsAddr string, policy Shutdown) error{
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	var tlsLn net.Listener
+	if tlsAddr != "" {
+		if tlsLn, err = net.Listen("tcp", tlsAddr); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+	return Serve(ctx, srv, ln, tlsLn, policy)
+}
@@ -0,0 +1,199 @@
+package server
+
+import(
+	"testing"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"net/http"
+	"path/filepath"
+	"time"
+	"github.com/tahasevim/responsiveweb/handlers"
+)
+
+//startServer serves handler on a random port and returns its URL and the channel of the result of Serve.
+func startServer(t *testing.T, ctx context.Context, handler http.Handler, policy Shutdown) (string, chan error){
+	ln, err := net.Listen("tcp","127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error,1)
+	go func(){
+		done <- Serve(ctx,&http.Server{Handler:handler},ln,nil,policy)
+	}()
+	return "http://"+ln.Addr().String(), done
+}
+
+func TestServeDrains(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	url, done := startServer(t,ctx,http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		close(started)
+		time.Sleep(200*time.Millisecond)
+		w.Write([]byte("done"))
+	}),Shutdown{Timeout:5*time.Second})
+
+	result := make(chan string,1)
+	go func(){
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		result <- string(body)
+	}()
+	<-started
+	cancel()
+	if body := <-result; body != "done"{
+		t.Errorf("In-flight request was not drained:%v",body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error:%v",err)
+	}
+}
+
+func TestServeCutsOff(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	url, done := startServer(t,ctx,http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		close(started)
+		select{
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}),Shutdown{Timeout:100*time.Millisecond})
+
+	go http.Get(url)
+	<-started
+	cancel()
+	select{
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error:%v",err)
+		}
+	case <-time.After(5*time.Second):
+		t.Fatal("Shutdown did not stop at the timeout")
+	}
+}
+
+func TestServeReadiness(t *testing.T){
+	//The router reports the package readiness, a handler of handlers.New its own.
+	for name, handler := range map[string]http.Handler{"router":handlers.NewRouter(),"instance":handlers.New()}{
+		t.Run(name,func(t *testing.T){
+			ctx, cancel := context.WithCancel(context.Background())
+			url, done := startServer(t,ctx,handler,Shutdown{Delay:500*time.Millisecond,Timeout:time.Second})
+			readyCode := func() int{
+				resp, err := http.Get(url+"/ready")
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			time.Sleep(50*time.Millisecond)
+			if code := readyCode(); code != http.StatusOK{
+				t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,code)
+			}
+			cancel()
+			//During the delay the server still answers, but it is not ready anymore.
+			time.Sleep(100*time.Millisecond)
+			if code := readyCode(); code != http.StatusServiceUnavailable{
+				t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusServiceUnavailable,code)
+			}
+			<-done
+		})
+	}
+}
+
+func TestServeNilHandler(t *testing.T){
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t,ctx,nil,Shutdown{Timeout:time.Second})
+	defer func(){
+		cancel()
+		<-done
+	}()
+	//Nothing is registered on http.DefaultServeMux, so it answers with its plain 404 page.
+	resp, err := http.Get(url+"/serve-nil-handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || string(body) != "404 page not found\n"{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v %q",http.StatusNotFound,resp.StatusCode,string(body))
+	}
+}
+
+func TestServeListenerError(t *testing.T){
+	ln, err := net.Listen("tcp","127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsLn, err := net.Listen("tcp","127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//TLS cannot be served without a certificate, so the TLS listener fails at once.
+	done := make(chan error,1)
+	go func(){
+		done <- Serve(context.Background(),&http.Server{Handler:handlers.NewRouter()},ln,tlsLn,Shutdown{Timeout:time.Second})
+	}()
+	select{
+	case err := <-done:
+		if err == nil || err == http.ErrServerClosed{
+			t.Errorf("Unexpected error:%v",err)
+		}
+	case <-time.After(5*time.Second):
+		t.Fatal("Serve did not return after the TLS listener failed")
+	}
+	if conn, err := net.Dial("tcp",ln.Addr().String()); err == nil{
+		conn.Close()
+		t.Error("The cleartext listener was not closed")
+	}
+}
+
+func TestServeTLS(t *testing.T){
+	dir, err := ioutil.TempDir("","certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert, err := LoadOrCreateCertificates(dir,[]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPEM, _ := ioutil.ReadFile(filepath.Join(dir,CAFile))
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	ln, err := net.Listen("tcp","127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error,1)
+	srv := &http.Server{Handler:handlers.NewRouter(),TLSConfig:&tls.Config{Certificates:[]tls.Certificate{cert}}}
+	go func(){
+		done <- Serve(ctx,srv,nil,ln,Shutdown{Timeout:time.Second})
+	}()
+	client := &http.Client{Transport:&http.Transport{TLSClientConfig:&tls.Config{RootCAs:roots}}}
+	resp, err := client.Get("https://"+ln.Addr().String()+"/tls-info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK{
+		t.Errorf("Unexpected result occurred.\nExpected Result:%v\n Result:%v",http.StatusOK,resp.StatusCode)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error:%v",err)
+	}
+}
@@ -0,0 +1,10 @@
+package templates
+
+import (
+	"embed"
+)
+
+//ExplorerFiles holds the page, script and style sheet of the API explorer served by /explorer.
+//They are embedded to the binary so that the explorer works without network access.
+//go:embed explorer
+var ExplorerFiles embed.FS
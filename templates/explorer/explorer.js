@@ -0,0 +1,189 @@
+// The explorer lists the endpoints of /routes and sends requests to them with fetch.
+(function () {
+	"use strict";
+
+	var bodyMethods = ["POST", "PUT", "PATCH", "DELETE"];
+	var routes = [];
+
+	function $(id) {
+		return document.getElementById(id);
+	}
+
+	// addPair appends a name/value row to the table with the given id.
+	function addPair(table, name, value) {
+		var row = document.createElement("tr");
+		["name", "value"].forEach(function (placeholder, i) {
+			var cell = document.createElement("td");
+			var input = document.createElement("input");
+			input.placeholder = placeholder;
+			input.value = [name, value][i] || "";
+			cell.appendChild(input);
+			row.appendChild(cell);
+		});
+		var cell = document.createElement("td");
+		var remove = document.createElement("button");
+		remove.type = "button";
+		remove.textContent = "×";
+		remove.onclick = function () {
+			row.remove();
+		};
+		cell.appendChild(remove);
+		row.appendChild(cell);
+		$(table).appendChild(row);
+	}
+
+	// pairs returns the non-empty name/value rows of the table with the given id.
+	function pairs(table) {
+		var result = [];
+		Array.prototype.forEach.call($(table).rows, function (row) {
+			var inputs = row.getElementsByTagName("input");
+			if (inputs[0].value !== "") {
+				result.push([inputs[0].value, inputs[1].value]);
+			}
+		});
+		return result;
+	}
+
+	function updateBodyEditor() {
+		$("body-editor").hidden = bodyMethods.indexOf($("method").value) < 0;
+	}
+
+	// selectRoute fills the request editors from the example of the route.
+	function selectRoute(route, item) {
+		Array.prototype.forEach.call($("routes").children, function (li) {
+			li.classList.toggle("selected", li === item);
+		});
+		$("title").textContent = route.pattern;
+		$("description").textContent = route.description;
+		var method = $("method");
+		method.innerHTML = "";
+		route.methods.concat(route.methods.indexOf("GET") >= 0 ? ["HEAD"] : [], ["OPTIONS"]).forEach(function (m) {
+			var option = document.createElement("option");
+			option.textContent = m;
+			method.appendChild(option);
+		});
+		var example = new URL(route.example || route.pattern, location.href);
+		$("path").value = example.pathname;
+		$("query").innerHTML = "";
+		example.searchParams.forEach(function (value, name) {
+			addPair("query", name, value);
+		});
+		$("headers").innerHTML = "";
+		addPair("headers", "Accept", route.produces || "*/*");
+		$("body").value = "";
+		updateBodyEditor();
+		history.replaceState(null, "", "#" + route.pattern);
+	}
+
+	function renderRoutes() {
+		var filter = $("filter").value.toLowerCase();
+		var list = $("routes");
+		list.innerHTML = "";
+		routes.forEach(function (route) {
+			if (filter && (route.pattern + " " + route.description).toLowerCase().indexOf(filter) < 0) {
+				return;
+			}
+			var item = document.createElement("li");
+			item.textContent = route.pattern + " ";
+			var methods = document.createElement("span");
+			methods.className = "methods";
+			methods.textContent = route.methods.join(", ");
+			item.appendChild(methods);
+			item.title = route.description;
+			item.onclick = function () {
+				selectRoute(route, item);
+			};
+			list.appendChild(item);
+			if (location.hash === "#" + route.pattern) {
+				selectRoute(route, item);
+			}
+		});
+	}
+
+	// renderBody shows the response body according to its content type.
+	function renderBody(contentType, blob) {
+		var target = $("response-body");
+		target.innerHTML = "";
+		if (contentType.indexOf("image/") === 0) {
+			var img = document.createElement("img");
+			img.src = URL.createObjectURL(blob);
+			target.appendChild(img);
+			return Promise.resolve();
+		}
+		var pre = document.createElement("pre");
+		target.appendChild(pre);
+		if (/^(text\/|application\/(json|xml|yaml))/.test(contentType) || contentType === "") {
+			return blob.text().then(function (text) {
+				if (contentType.indexOf("application/json") === 0) {
+					try {
+						text = JSON.stringify(JSON.parse(text), null, 2);
+					} catch (e) {
+						// Streams like /stream/:n send one JSON document per line.
+					}
+				}
+				pre.textContent = text;
+			});
+		}
+		return blob.arrayBuffer().then(function (buf) {
+			var bytes = new Uint8Array(buf);
+			var hex = [];
+			for (var i = 0; i < bytes.length && i < 512; i++) {
+				hex.push(("0" + bytes[i].toString(16)).slice(-2));
+			}
+			pre.textContent = bytes.length + " bytes" + (bytes.length > 512 ? ", first 512 bytes" : "") + ":\n" + hex.join(" ");
+		});
+	}
+
+	function send(event) {
+		event.preventDefault();
+		var url = new URL($("path").value, location.href);
+		pairs("query").forEach(function (p) {
+			url.searchParams.append(p[0], p[1]);
+		});
+		var headers = new Headers();
+		pairs("headers").forEach(function (p) {
+			headers.append(p[0], p[1]);
+		});
+		var init = {method: $("method").value, headers: headers};
+		if (bodyMethods.indexOf(init.method) >= 0 && $("body").value !== "") {
+			headers.set("Content-Type", $("content-type").value);
+			init.body = $("body").value;
+		}
+		var started = Date.now();
+		$("response").hidden = false;
+		$("status").className = "";
+		$("status").textContent = "...";
+		fetch(url, init).then(function (resp) {
+			$("status").textContent = resp.status + " " + resp.statusText;
+			$("status").className = resp.ok ? "" : "error";
+			var lines = [];
+			resp.headers.forEach(function (value, name) {
+				lines.push(name + ": " + value);
+			});
+			$("response-headers").textContent = lines.join("\n");
+			return resp.blob().then(function (blob) {
+				return renderBody(resp.headers.get("Content-Type") || "", blob);
+			});
+		}).then(function () {
+			$("elapsed").textContent = "in " + (Date.now() - started) + " ms";
+		}).catch(function (err) {
+			$("status").textContent = err.message;
+			$("status").className = "error";
+		});
+	}
+
+	$("request").addEventListener("submit", send);
+	$("method").addEventListener("change", updateBodyEditor);
+	$("filter").addEventListener("input", renderRoutes);
+	document.querySelectorAll("[data-add]").forEach(function (button) {
+		button.onclick = function () {
+			addPair(button.getAttribute("data-add"));
+		};
+	});
+	fetch("/routes").then(function (resp) {
+		return resp.json();
+	}).then(function (data) {
+		routes = data;
+		renderRoutes();
+	});
+})();
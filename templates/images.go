@@ -0,0 +1,17 @@
+package templates
+
+import (
+	_ "embed"
+)
+
+//The images below are served by the /image endpoints, they are embedded to the binary so that no network access is needed.
+var(
+	//go:embed images/pig.png
+	PngImage []byte
+	//go:embed images/pig.jpeg
+	JpegImage []byte
+	//go:embed images/pig.webp
+	WebpImage []byte
+	//go:embed images/pig.svg
+	SvgImage []byte
+)